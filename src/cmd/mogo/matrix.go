@@ -4,16 +4,39 @@
 
 // +build ignore
 
+// The matrix example is spread over the matrix*.go files.
+// Run it with:
+//
+//	mogo matrix*.go
+//
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+)
 
 const boundsChecks = true
 
-type T float64 // for convenience
+// T is the element type of Vector and Matrix. The language has no type
+// parameters, so Vector[T] and Matrix[T] cannot be written; instead, all
+// code is written in terms of T (converting to float64 only to call
+// package math), and a float32 build is obtained by changing this
+// declaration and epsilon; the code that depends on the bit layout of
+// T (atomicAdd and split) chooses by the size of T. Complex elements
+// need different kernels (abs, ordering, conjugation) and live in
+// CMatrix instead.
+type T float64
+
+func abs(x T) T  { return T(math.Abs(float64(x))) }
+func sqrt(x T) T { return T(math.Sqrt(float64(x))) }
 
 type Vector struct {
 	array       []T // may be longer than len
+	off         int // index of x[0] in array
 	len, stride int
 }
 
@@ -21,7 +44,7 @@ func (x *Vector) addr(i int) *T {
 	if boundsChecks && uint(i) >= uint(x.len) {
 		panic("index out of bounds")
 	}
-	return &x.array[i*x.stride]
+	return &x.array[x.off+i*x.stride]
 }
 
 func (x *Vector) Len() int        { return x.len }
@@ -33,6 +56,9 @@ func (x *Vector) * (y *Vector) T {
 	if x.Len() != y.Len() {
 		panic("incompatible vector lengths")
 	}
+	if x.stride == 1 && y.stride == 1 {
+		return dotUnitary(x.array[x.off:][:x.len], y.array[y.off:][:y.len])
+	}
 	var t T
 	for i := x.Len() - 1; i >= 0; i-- {
 		t += x[i] * y[i]
@@ -40,12 +66,21 @@ func (x *Vector) * (y *Vector) T {
 	return t
 }
 
+// Slice returns the subvector of elements i0 through i1-1 of x, as a
+// view sharing x's storage.
+func (x *Vector) Slice(i0, i1 int) *Vector {
+	if i0 < 0 || i1 < i0 || i1 > x.len {
+		panic("index out of bounds")
+	}
+	return &Vector{x.array, x.off + i0*x.stride, i1 - i0, x.stride}
+}
+
 func (x *Vector) GoSlice() []T {
 	if x.stride == 1 {
-		return x.array[:x.len]
+		return x.array[x.off : x.off+x.len]
 	}
 	s := make([]T, x.len)
-	j := 0
+	j := x.off
 	for i := range s {
 		s[i] = x.array[j]
 		j += x.stride
@@ -53,46 +88,256 @@ func (x *Vector) GoSlice() []T {
 	return s
 }
 
-type dim [2]int
+// Copy returns a copy of x with its own (contiguous) storage.
+func (x *Vector) Copy() *Vector {
+	y := NewVector(x.len)
+	for i := 0; i < x.len; i++ {
+		y[i] = x[i]
+	}
+	return y
+}
+
+func NewVector(n int) *Vector {
+	if n < 0 {
+		panic("invalid length")
+	}
+	return &Vector{make([]T, n), 0, n, 1}
+}
+
+// A Shape is a pair of numbers (rows, columns), used for the size and
+// the element strides of a matrix.
+type Shape [2]int
+
+func (s Shape) Rows() int { return s[0] }
+func (s Shape) Cols() int { return s[1] }
+
+// Size returns the number of elements of a matrix of shape s.
+func (s Shape) Size() int { return s[0] * s[1] }
 
-func (d dim) transpose() dim { return dim{d[1], d[0]} }
+func (s Shape) transpose() Shape { return Shape{s[1], s[0]} }
 
 type Matrix struct {
 	array       []T
-	len, stride dim
+	off         int // index of m[0, 0] in array
+	len, stride Shape
 }
 
 func (m *Matrix) addr(i, j int) *T {
-	if boundsChecks && uint(i) >= uint(m.len[0]) || uint(j) >= uint(m.len[1]) {
+	if boundsChecks && (uint(i) >= uint(m.len[0]) || uint(j) >= uint(m.len[1])) {
 		panic("index out of bounds")
 	}
-	return &m.array[i*m.stride[0]+j*m.stride[1]]
+	return &m.array[m.off+i*m.stride[0]+j*m.stride[1]]
 }
 
 func (m *Matrix) Len() (int, int)    { return m.len[0], m.len[1] }
 func (m *Matrix) [] (i, j int) T     { return *m.addr(i, j) }
 func (m *Matrix) []= (i, j int, x T) { *m.addr(i, j) = x }
 
-func (m *Matrix) Row(i int) *Vector { return &Vector{m.array[i*m.stride[0]:], m.len[1], m.stride[1]} }
-func (m *Matrix) Col(j int) *Vector { return &Vector{m.array[j*m.stride[1]:], m.len[0], m.stride[0]} }
+// Shape returns the number of rows and columns of m.
+func (m *Matrix) Shape() Shape { return m.len }
+
+// Stride returns the distances in the underlying storage between
+// elements of adjacent rows and of adjacent columns of m. Views such
+// as Transpose and FlipUD have non-row-major (possibly negative)
+// strides.
+func (m *Matrix) Stride() Shape { return m.stride }
+
+// SameShape reports whether m and b have the same number of rows and
+// columns.
+func (m *Matrix) SameShape(b *Matrix) bool { return m.len == b.len }
+
+func (m *Matrix) Row(i int) *Vector {
+	return &Vector{m.array, m.off + i*m.stride[0], m.len[1], m.stride[1]}
+}
+func (m *Matrix) Col(j int) *Vector {
+	return &Vector{m.array, m.off + j*m.stride[1], m.len[0], m.stride[0]}
+}
+
+// Slice returns the submatrix of rows i0 through i1-1 and columns j0
+// through j1-1 of m, as a view sharing m's storage.
+func (m *Matrix) Slice(i0, i1, j0, j1 int) *Matrix {
+	if i0 < 0 || i1 < i0 || i1 > m.len[0] || j0 < 0 || j1 < j0 || j1 > m.len[1] {
+		panic("index out of bounds")
+	}
+	return &Matrix{m.array, m.off + i0*m.stride[0] + j0*m.stride[1], Shape{i1 - i0, j1 - j0}, m.stride}
+}
+
+// rowRange returns a view of rows i0 through i1-1 of m.
+func rowRange(m *Matrix, i0, i1 int) *Matrix {
+	return m.Slice(i0, i1, 0, m.len[1])
+}
 
 func (a *Matrix) Transpose() *Matrix {
 	return &Matrix{
 		a.array,
+		a.off,
 		a.len.transpose(),
 		a.stride.transpose(),
 	}
 }
 
+// FlipUD returns a view of a with the order of the rows reversed.
+// Like all views, it shares a's storage; use Copy to materialize it.
+func (a *Matrix) FlipUD() *Matrix {
+	f := *a
+	if n := a.len[0]; n > 0 {
+		f.off += (n - 1) * a.stride[0]
+	}
+	f.stride[0] = -a.stride[0]
+	return &f
+}
+
+// FlipLR returns a view of a with the order of the columns reversed.
+// Like all views, it shares a's storage; use Copy to materialize it.
+func (a *Matrix) FlipLR() *Matrix {
+	f := *a
+	if m := a.len[1]; m > 0 {
+		f.off += (m - 1) * a.stride[1]
+	}
+	f.stride[1] = -a.stride[1]
+	return &f
+}
+
+// Rotate90 returns a view of a rotated counterclockwise by k*90 degrees;
+// k may be negative. Like all views, it shares a's storage; use Copy
+// to materialize it.
+func (a *Matrix) Rotate90(k int) *Matrix {
+	switch k & 3 {
+	case 1:
+		return a.Transpose().FlipUD()
+	case 2:
+		return a.FlipUD().FlipLR()
+	case 3:
+		return a.Transpose().FlipLR()
+	}
+	f := *a
+	return &f
+}
+
+// Roll shifts the elements of a circularly by k positions along the
+// given axis (0 for rows, 1 for columns), in place: the element at row
+// (or column) index i moves to index i+k modulo the axis length; k may
+// be negative.
+func (a *Matrix) Roll(axis, k int) {
+	if axis != 0 && axis != 1 {
+		panic("invalid axis")
+	}
+	n, m := a.Len()
+	if n == 0 || m == 0 {
+		return
+	}
+	k = mod(k, a.len[axis])
+	if k == 0 {
+		return
+	}
+
+	// contiguous data: rotate slices in place via three reversals
+	if a.stride[1] == 1 {
+		if axis == 1 {
+			for i := 0; i < n; i++ {
+				off := a.off + i*a.stride[0]
+				rotate(a.array[off:off+m], k)
+			}
+			return
+		}
+		if a.stride[0] == m {
+			rotate(a.array[a.off:a.off+n*m], k*m)
+			return
+		}
+	}
+
+	// strided view: copy
+	c := a.Copy()
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			if axis == 0 {
+				a[(i+k)%n, j] = c[i, j]
+			} else {
+				a[i, (j+k)%m] = c[i, j]
+			}
+		}
+	}
+}
+
+// rotate rotates s to the right by 0 <= k <= len(s) elements.
+func rotate(s []T, k int) {
+	reverse(s)
+	reverse(s[:k])
+	reverse(s[k:])
+}
+
+func reverse(s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// transposeBlock is the block size used by TransposeCopy: a block
+// of the source and of the destination fit into the L1 cache together.
+const transposeBlock = 32
+
+// TransposeCopy returns the transpose of a with its own row-major
+// storage. Unlike Transpose, which only swaps the strides of a, the
+// result is laid out for fast row-wise access. The elements are copied
+// block by block so that both source and destination are accessed in
+// a cache-friendly way.
+func (a *Matrix) TransposeCopy() *Matrix {
+	n, m := a.Len()
+	return TransposeCopyTo(NewMatrix(m, n), a)
+}
+
 func NewMatrix(n, m int) *Matrix {
 	if n < 0 || m < 0 {
 		panic("invalid length")
 	}
 	return &Matrix{
 		array:  make([]T, n*m),
-		len:    dim{n, m},
-		stride: dim{m, 1}, // row-major
+		len:    Shape{n, m},
+		stride: Shape{m, 1}, // row-major
+	}
+}
+
+// Zeros returns a new n×m matrix of zeros; it is equivalent to
+// NewMatrix(n, m).
+func Zeros(n, m int) *Matrix { return NewMatrix(n, m) }
+
+// Ones returns a new n×m matrix with all elements 1.
+func Ones(n, m int) *Matrix {
+	a := NewMatrix(n, m)
+	for i := range a.array {
+		a.array[i] = 1
+	}
+	return a
+}
+
+// Identity returns the n×n identity matrix.
+func Identity(n int) *Matrix { return Eye(n, n, 0) }
+
+// Eye returns the n×m matrix with ones on its k-th diagonal (see
+// DiagOffset) and zeros elsewhere. If the k-th diagonal lies outside
+// the matrix, the result is all zeros.
+func Eye(n, m, k int) *Matrix {
+	a := NewMatrix(n, m)
+	if k >= m || -k >= n {
+		return a
 	}
+	d := a.DiagOffset(k)
+	for i := 0; i < d.Len(); i++ {
+		d[i] = 1
+	}
+	return a
+}
+
+// Copy returns a copy of a with its own (row-major) storage.
+func (a *Matrix) Copy() *Matrix {
+	n, m := a.Len()
+	c := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[i, j] = a[i, j]
+		}
+	}
+	return c
 }
 
 func (a *Matrix) Set(coeff ...T) {
@@ -109,34 +354,61 @@ func (a *Matrix) Set(coeff ...T) {
 	}
 }
 
-func (a *Matrix) Print() {
+// PrintOptions controls the text output of Fprint.
+// A nil *PrintOptions is equivalent to the zero value.
+type PrintOptions struct {
+	// Format is the fmt format for each element; "" means "%5g".
+	// Elements are separated by a blank.
+	Format string
+}
+
+// Fprint writes a as text to w, one row per line, followed by an
+// empty line.
+func (a *Matrix) Fprint(w io.Writer, opts *PrintOptions) error {
+	_, err := a.fprint(w, opts)
+	return err
+}
+
+func (a *Matrix) fprint(w io.Writer, opts *PrintOptions) (int64, error) {
+	format := " %5g"
+	if opts != nil && opts.Format != "" {
+		format = " " + opts.Format
+	}
+	var total int64
 	n, m := a.Len()
 	for i := 0; i < n; i++ {
 		for j := 0; j < m; j++ {
-			fmt.Printf(" %5g", a[i, j])
+			k, err := fmt.Fprintf(w, format, a[i, j])
+			total += int64(k)
+			if err != nil {
+				return total, err
+			}
+		}
+		k, err := fmt.Fprintln(w)
+		total += int64(k)
+		if err != nil {
+			return total, err
 		}
-		fmt.Println()
 	}
-	fmt.Println()
+	k, err := fmt.Fprintln(w)
+	return total + int64(k), err
+}
+
+// WriteTo writes a as text to w like Fprint with the default options,
+// and returns the number of bytes written. It implements io.WriterTo.
+func (a *Matrix) WriteTo(w io.Writer) (int64, error) {
+	return a.fprint(w, nil)
+}
+
+// Print writes a as text to standard output (see Fprint).
+func (a *Matrix) Print() {
+	a.Fprint(os.Stdout, nil)
 }
 
 func (a *Matrix) * (b *Matrix) *Matrix {
-	n, m := a.Len()
-	o, p := b.Len()
-	if m != o {
-		panic("incompatible matrix sizes")
-	}
-	c := NewMatrix(n, p)
-	for i := 0; i < n; i++ {
-		for j := 0; j < p; j++ {
-			var t T
-			for k := 0; k < m; k++ {
-				t += a[i, k] * b[k, j]
-			}
-			c[i, j] = t
-		}
-	}
-	return c
+	n, _ := a.Len()
+	_, p := b.Len()
+	return MulTo(NewMatrix(n, p), a, b)
 }
 
 func (a *Matrix) Mul(b *Matrix) *Matrix {
@@ -156,37 +428,151 @@ func (a *Matrix) Mul(b *Matrix) *Matrix {
 	return c
 }
 
-func main() {
-	a := NewMatrix(4, 5)
-	a.Set(
-		4, 2, 7, 9, 1,
-		5, 0, 1, 8, 3,
-		5, 6, 3, 2, 1,
-		7, 9, 0, 1, 2,
-	)
-
-	b := NewMatrix(5, 3)
-	b.Set(
-		3, 4, 5,
-		0, 3, 1,
-		3, 2, 1,
-		8, 2, 6,
-		2, 7, 1,
-	)
-
-	(a * b).Print()
+// MulVec returns the matrix-vector product a*x. Together with
+// MulVecTrans, it makes a *Matrix a LinearOperator and a
+// TransposeOperator.
+func (a *Matrix) MulVec(x *Vector) *Vector {
+	n, m := a.Len()
+	if x.Len() != m {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(n)
+	if a.stride[1] == 1 && x.stride == 1 {
+		// contiguous rows: dot products of slices
+		xs := x.array[x.off : x.off+m]
+		for i := 0; i < n; i++ {
+			row := a.array[a.off+i*a.stride[0]:][:m]
+			var t T
+			for j, v := range row {
+				t += v * xs[j]
+			}
+			y[i] = t
+		}
+		return y
+	}
+	for i := 0; i < n; i++ {
+		var t T
+		for j := 0; j < m; j++ {
+			t += a[i, j] * x[j]
+		}
+		y[i] = t
+	}
+	return y
+}
 
-	c := a.Mul(b)
-	c.Print()
+// MulVecTrans returns the matrix-vector product aᵀ*x (equivalently,
+// the row vector xᵀ*a), accumulating x[i] times row i of a so that a
+// is traversed in storage order.
+func (a *Matrix) MulVecTrans(x *Vector) *Vector {
+	n, m := a.Len()
+	if x.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(m)
+	if a.stride[1] == 1 {
+		ys := y.array
+		for i := 0; i < n; i++ {
+			xi := x[i]
+			if xi == 0 {
+				continue
+			}
+			row := a.array[a.off+i*a.stride[0]:][:m]
+			for j, v := range row {
+				ys[j] += xi * v
+			}
+		}
+		return y
+	}
+	for i := 0; i < n; i++ {
+		xi := x[i]
+		for j := 0; j < m; j++ {
+			y[j] += xi * a[i, j]
+		}
+	}
+	return y
+}
 
-	c.Transpose().Print()
+// randOrthogonal returns a random n×n orthogonal matrix, accumulated
+// from Householder reflections of normally distributed vectors drawn
+// from r.
+func randOrthogonal(n int, r *rand.Rand) *Matrix {
+	q := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		q[i, i] = 1
+	}
+	v := make([]T, n)
+	for k := 0; k < n; k++ {
+		var s T
+		for i := k; i < n; i++ {
+			v[i] = T(r.NormFloat64())
+			s += v[i] * v[i]
+		}
+		if s == 0 {
+			continue
+		}
+		// q = q * (I - 2vvᵀ/s), touching columns k..n-1 only
+		for i := 0; i < n; i++ {
+			var t T
+			for j := k; j < n; j++ {
+				t += q[i, j] * v[j]
+			}
+			t *= 2 / s
+			for j := k; j < n; j++ {
+				q[i, j] = q[i, j] - t*v[j]
+			}
+		}
+	}
+	return q
+}
 
-	n, m := c.Len()
+// spectral returns u * diag(s) * vᵀ.
+func spectral(u *Matrix, s []T, v *Matrix) *Matrix {
+	n := len(s)
+	c := NewMatrix(n, n)
 	for i := 0; i < n; i++ {
-		fmt.Println(c.Row(i).GoSlice())
+		for j := 0; j < n; j++ {
+			var t T
+			for k := 0; k < n; k++ {
+				t += u[i, k] * s[k] * v[j, k]
+			}
+			c[i, j] = t
+		}
 	}
+	return c
+}
 
-	for j := 0; j < m; j++ {
-		fmt.Println(c.Col(j).GoSlice())
+// RandSPD returns a random n×n symmetric positive definite matrix
+// with 2-norm condition number cond. The eigenvalues are spaced
+// geometrically between 1 and 1/cond. r is the source of randomness.
+func RandSPD(n int, cond float64, r *rand.Rand) *Matrix {
+	if n < 0 {
+		panic("invalid length")
 	}
+	if cond < 1 {
+		panic("condition number must be >= 1")
+	}
+	s := make([]T, n)
+	for k := range s {
+		s[k] = 1
+		if n > 1 {
+			s[k] = T(math.Pow(cond, -float64(k)/float64(n-1)))
+		}
+	}
+	q := randOrthogonal(n, r)
+	c := spectral(q, s, q)
+	// remove rounding asymmetry
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			c[j, i] = c[i, j]
+		}
+	}
+	return c
+}
+
+// RandWithSpectrum returns a random square matrix whose singular
+// values are the elements of singularValues (in any order), using r as
+// the source of randomness.
+func RandWithSpectrum(singularValues *Vector, r *rand.Rand) *Matrix {
+	n := singularValues.Len()
+	return spectral(randOrthogonal(n, r), singularValues.GoSlice(), randOrthogonal(n, r))
 }
@@ -0,0 +1,114 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// An Arena allocates the temporary matrices and vectors of a
+// computation by bumping an offset in large blocks of memory, and
+// frees all of them at once with Reset, so that an algorithm with
+// many short-lived intermediates produces no garbage once the arena
+// has grown to the size of one round:
+//
+//	var ar Arena
+//	for each iteration {
+//		ar.Reset()
+//		t := MulTo(ar.NewMatrix(n, p), a, b)
+//		u := AddTo(ar.NewMatrix(n, p), t, c)
+//		...
+//	}
+//
+// The operators (a*b, x+y, ...) allocate their results as usual; use
+// the destination variants (MulTo, AddTo, ...) with arena matrices to
+// avoid that. Matrices and vectors from an arena are valid until the
+// next Reset, after which their storage is handed out again; results
+// that must survive a Reset have to be copied. Unlike a Workspace,
+// which reuses a buffer per call site, an Arena serves requests of any
+// size in any order. The zero value is an empty arena ready to use. A
+// nil *Arena is valid as well and simply allocates. An Arena must not
+// be used by multiple goroutines concurrently.
+type Arena struct {
+	blocks [][]T // blocks[len(blocks)-1] is the one being filled
+	used   int   // elements used of the last block
+	total  int   // elements of all blocks
+
+	mats []Matrix // headers handed out by NewMatrix
+	vecs []Vector // headers handed out by NewVector
+	nm   int
+	nv   int
+}
+
+// arenaBlock is the minimum number of elements of an arena block.
+const arenaBlock = 1 << 16
+
+// Reset frees all matrices and vectors allocated from ar. If the
+// previous round needed more than one block, they are replaced by a
+// single block large enough for all of them.
+func (ar *Arena) Reset() {
+	if len(ar.blocks) > 1 {
+		ar.blocks = [][]T{make([]T, ar.total)}
+	}
+	ar.used, ar.nm, ar.nv = 0, 0, 0
+}
+
+// alloc returns a zeroed slice of n elements from the current block,
+// starting a new block if it does not fit.
+func (ar *Arena) alloc(n int) []T {
+	if k := len(ar.blocks); k == 0 || ar.used+n > len(ar.blocks[k-1]) {
+		size := ar.total
+		if size < arenaBlock {
+			size = arenaBlock
+		}
+		if size < n {
+			size = n
+		}
+		ar.blocks = append(ar.blocks, make([]T, size))
+		ar.total += size
+		ar.used = 0
+	}
+	buf := ar.blocks[len(ar.blocks)-1][ar.used:][:n:n]
+	ar.used += n
+	for i := range buf {
+		buf[i] = 0
+	}
+	return buf
+}
+
+// NewMatrix returns a new n×m matrix of zeros allocated from ar.
+func (ar *Arena) NewMatrix(n, m int) *Matrix {
+	if ar == nil {
+		return NewMatrix(n, m)
+	}
+	if n < 0 || m < 0 {
+		panic("invalid length")
+	}
+	if ar.nm == len(ar.mats) {
+		// matrices handed out keep pointing into the old slice
+		ar.mats = make([]Matrix, 2*len(ar.mats)+16)
+		ar.nm = 0
+	}
+	a := &ar.mats[ar.nm]
+	ar.nm++
+	*a = Matrix{ar.alloc(n * m), 0, Shape{n, m}, Shape{m, 1}}
+	return a
+}
+
+// NewVector returns a new vector of n zeros allocated from ar.
+func (ar *Arena) NewVector(n int) *Vector {
+	if ar == nil {
+		return NewVector(n)
+	}
+	if n < 0 {
+		panic("invalid length")
+	}
+	if ar.nv == len(ar.vecs) {
+		ar.vecs = make([]Vector, 2*len(ar.vecs)+16)
+		ar.nv = 0
+	}
+	x := &ar.vecs[ar.nv]
+	ar.nv++
+	*x = Vector{ar.alloc(n), 0, n, 1}
+	return x
+}
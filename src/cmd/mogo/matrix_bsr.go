@@ -0,0 +1,181 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "sort"
+
+// A BSR is a sparse matrix in block compressed sparse row format: the
+// matrix is divided into dense r×c blocks, and only the blocks with
+// nonzero elements are stored. Block row I holds the blocks with block
+// column indices ind[ptr[I]:ptr[I+1]], in increasing order; block k is
+// stored in row-major order in val[k*r*c:(k+1)*r*c].
+//
+// For matrices with a natural block structure, such as finite element
+// matrices with several unknowns per node, BSR needs one index per
+// block instead of one per element, and multiplication works on small
+// dense blocks.
+type BSR struct {
+	len   Shape // size in elements
+	block Shape // size of the blocks, r×c
+	ptr   []int // len[0]/r+1 block row pointers
+	ind   []int
+	val   []T
+}
+
+// BSR returns s in BSR format with r×c blocks. The size of s must be
+// a multiple of the block size.
+func (s *CSR) BSR(r, c int) *BSR {
+	n, m := s.Len()
+	if r <= 0 || c <= 0 || n%r != 0 || m%c != 0 {
+		panic("invalid block size")
+	}
+	nb, mb := n/r, m/c
+	b := &BSR{len: s.len, block: Shape{r, c}, ptr: make([]int, nb+1)}
+
+	// pos[J] is the index of block J in the current block row, or -1
+	pos := make([]int, mb)
+	for J := range pos {
+		pos[J] = -1
+	}
+	for I := 0; I < nb; I++ {
+		lo := len(b.ind)
+		for i := I * r; i < (I+1)*r; i++ {
+			for k := s.ptr[i]; k < s.ptr[i+1]; k++ {
+				if J := s.ind[k] / c; pos[J] < 0 {
+					pos[J] = 0
+					b.ind = append(b.ind, J)
+				}
+			}
+		}
+		row := b.ind[lo:]
+		sort.Ints(row)
+		for k, J := range row {
+			pos[J] = lo + k
+		}
+		b.val = append(b.val, make([]T, len(row)*r*c)...)
+		for i := I * r; i < (I+1)*r; i++ {
+			for k := s.ptr[i]; k < s.ptr[i+1]; k++ {
+				j := s.ind[k]
+				b.val[pos[j/c]*r*c+(i-I*r)*c+j%c] = s.val[k]
+			}
+		}
+		for _, J := range row {
+			pos[J] = -1
+		}
+		b.ptr[I+1] = len(b.ind)
+	}
+	return b
+}
+
+func (b *BSR) Len() (int, int) { return b.len[0], b.len[1] }
+
+// BlockSize returns the size r×c of the blocks of b.
+func (b *BSR) BlockSize() (int, int) { return b.block[0], b.block[1] }
+
+func (b *BSR) [] (i, j int) T {
+	if boundsChecks && (uint(i) >= uint(b.len[0]) || uint(j) >= uint(b.len[1])) {
+		panic("index out of bounds")
+	}
+	r, c := b.BlockSize()
+	I, J := i/r, j/c
+	ind := b.ind[b.ptr[I]:b.ptr[I+1]]
+	if k := sort.SearchInts(ind, J); k < len(ind) && ind[k] == J {
+		return b.val[(b.ptr[I]+k)*r*c+(i%r)*c+j%c]
+	}
+	return 0
+}
+
+// NNZ returns the number of stored elements of b, including zeros
+// inside the stored blocks.
+func (b *BSR) NNZ() int { return len(b.val) }
+
+// NonZeros calls f for each stored element of b, including zeros
+// inside the stored blocks, block by block in row-major block order,
+// until f returns false.
+func (b *BSR) NonZeros(f func(i, j int, v T) bool) {
+	r, c := b.BlockSize()
+	for I := 0; I < b.len[0]/r; I++ {
+		for k := b.ptr[I]; k < b.ptr[I+1]; k++ {
+			blk := b.val[k*r*c : (k+1)*r*c]
+			for u := 0; u < r; u++ {
+				for v := 0; v < c; v++ {
+					if !f(I*r+u, b.ind[k]*c+v, blk[u*c+v]) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// MulVec returns the matrix-vector product b*x.
+func (b *BSR) MulVec(x *Vector) *Vector {
+	n, m := b.Len()
+	if x.Len() != m {
+		panic("incompatible matrix and vector sizes")
+	}
+	r, c := b.BlockSize()
+	y := NewVector(n)
+	t := make([]T, r)
+	for I := 0; I < n/r; I++ {
+		for u := range t {
+			t[u] = 0
+		}
+		for k := b.ptr[I]; k < b.ptr[I+1]; k++ {
+			blk := b.val[k*r*c : (k+1)*r*c]
+			j0 := b.ind[k] * c
+			for u := 0; u < r; u++ {
+				s := t[u]
+				for v := 0; v < c; v++ {
+					s += blk[u*c+v] * x[j0+v]
+				}
+				t[u] = s
+			}
+		}
+		for u, s := range t {
+			y[I*r+u] = s
+		}
+	}
+	return y
+}
+
+// Dense returns b as a dense matrix.
+func (b *BSR) Dense() *Matrix {
+	n, m := b.Len()
+	r, c := b.BlockSize()
+	a := NewMatrix(n, m)
+	for I := 0; I < n/r; I++ {
+		for k := b.ptr[I]; k < b.ptr[I+1]; k++ {
+			blk := b.val[k*r*c : (k+1)*r*c]
+			for u := 0; u < r; u++ {
+				for v := 0; v < c; v++ {
+					a[I*r+u, b.ind[k]*c+v] = blk[u*c+v]
+				}
+			}
+		}
+	}
+	return a
+}
+
+// CSR returns b in CSR format. Zero elements inside stored blocks
+// are dropped.
+func (b *BSR) CSR() *CSR {
+	n, _ := b.Len()
+	r, c := b.BlockSize()
+	t := &COO{len: b.len}
+	for I := 0; I < n/r; I++ {
+		for k := b.ptr[I]; k < b.ptr[I+1]; k++ {
+			blk := b.val[k*r*c : (k+1)*r*c]
+			for u := 0; u < r; u++ {
+				for v := 0; v < c; v++ {
+					t.Append(I*r+u, b.ind[k]*c+v, blk[u*c+v])
+				}
+			}
+		}
+	}
+	return t.CSR()
+}
@@ -0,0 +1,242 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A LinearOperator is a linear map given only by its action on
+// vectors, such as a *CSR. Iterative solvers need nothing else.
+type LinearOperator interface {
+	Len() (int, int)
+	MulVec(x *Vector) *Vector
+}
+
+// A Preconditioner approximates the inverse of a linear operator a:
+// Precondition returns an approximation of the solution z of a*z = r.
+type Preconditioner interface {
+	Precondition(r *Vector) *Vector
+}
+
+// CGOptions controls the conjugate gradient method.
+// A nil *CGOptions is equivalent to the zero value.
+type CGOptions struct {
+	// Tol is the convergence threshold for the relative residual
+	// norm |b - a*x| / |b|; 0 means 1e-10.
+	Tol T
+	// MaxIter is the maximum number of iterations; 0 means 10*n.
+	MaxIter int
+	// Precond, if not nil, is used as preconditioner.
+	// It must be symmetric positive definite.
+	Precond Preconditioner
+	// Checkpointer, if not nil, is called after every iteration with
+	// the iterate, the residual, and the search direction as state
+	// (n×1 matrices).
+	Checkpointer Checkpointer
+	// Resume, if not nil, is a checkpoint of a previous run for the
+	// same system to continue from, instead of starting from x = 0.
+	Resume *Checkpoint
+}
+
+// CG solves the symmetric positive definite system a*x = b with the
+// (preconditioned) conjugate gradient method, starting from x = 0.
+// It returns the solution and the number of iterations performed.
+// If the method does not converge within the iteration limit, the
+// result is the last iterate along with ErrNoConvergence.
+func CG(a LinearOperator, b *Vector, opts *CGOptions) (x *Vector, iters int, err error) {
+	if opts == nil {
+		opts = new(CGOptions)
+	}
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	tol, maxIter := opts.Tol, opts.MaxIter
+	if tol <= 0 {
+		tol = 1e-10
+	}
+	if maxIter <= 0 {
+		maxIter = 10 * n
+	}
+
+	x = NewVector(n)
+	bnorm := sqrt(b * b)
+	if bnorm == 0 {
+		return x, 0, nil
+	}
+	r := b.Copy()
+	z := precondition(opts.Precond, r)
+	p := z.Copy()
+	if c := opts.Resume; c != nil {
+		s := checkpointState(c, 3)
+		x, r, p = checkpointVector(s[0], n), checkpointVector(s[1], n), checkpointVector(s[2], n)
+		z = precondition(opts.Precond, r)
+		iters = c.Iter
+	}
+	rz := r * z
+	for {
+		if sqrt(r*r) <= tol*bnorm {
+			return x, iters, nil
+		}
+		if iters == maxIter {
+			return x, iters, ErrNoConvergence
+		}
+		iters++
+		q := a.MulVec(p)
+		alpha := rz / (p * q)
+		for i := 0; i < n; i++ {
+			x[i] += alpha * p[i]
+			r[i] -= alpha * q[i]
+		}
+		z = precondition(opts.Precond, r)
+		rz1 := r * z
+		beta := rz1 / rz
+		rz = rz1
+		for i := 0; i < n; i++ {
+			p[i] = z[i] + beta*p[i]
+		}
+		if cp := opts.Checkpointer; cp != nil {
+			state := []*Matrix{colMatrix(x), colMatrix(r), colMatrix(p)}
+			if err := cp.Checkpoint(&Checkpoint{iters, state}); err != nil {
+				return x, iters, err
+			}
+		}
+	}
+}
+
+func precondition(m Preconditioner, r *Vector) *Vector {
+	if m == nil {
+		return r.Copy()
+	}
+	return m.Precondition(r)
+}
+
+// Jacobi is the diagonal (Jacobi) preconditioner: it divides each
+// element by the corresponding diagonal element of the operator.
+type Jacobi struct {
+	diag []T
+}
+
+// NewJacobi returns the Jacobi preconditioner for the square matrix s.
+func NewJacobi(s *CSR) *Jacobi {
+	n, m := s.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	diag := make([]T, n)
+	for i := range diag {
+		diag[i] = s[i, i]
+		if diag[i] == 0 {
+			diag[i] = 1
+		}
+	}
+	return &Jacobi{diag}
+}
+
+func (p *Jacobi) Precondition(r *Vector) *Vector {
+	z := NewVector(r.Len())
+	for i := range p.diag {
+		z[i] = r[i] / p.diag[i]
+	}
+	return z
+}
+
+// IncompleteCholesky is the zero fill-in incomplete Cholesky (IC(0))
+// preconditioner: a lower triangular factor l with the sparsity
+// pattern of the lower triangle of the operator, such that l*lᵀ
+// approximates it.
+type IncompleteCholesky struct {
+	l *CSR
+}
+
+// NewIncompleteCholesky computes the IC(0) factorization of the
+// symmetric matrix s; only its lower triangle is used. If a nonpositive
+// pivot is encountered, which may happen even if s is positive
+// definite (but not if s is an M-matrix, like a Laplacian), the result
+// is ErrNotSPD.
+func NewIncompleteCholesky(s *CSR) (*IncompleteCholesky, error) {
+	n, m := s.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	// l starts out as the lower triangle of s, with the diagonal
+	// element last in each row
+	l := &CSR{len: s.len, ptr: make([]int, n+1)}
+	for i := 0; i < n; i++ {
+		for k := s.ptr[i]; k < s.ptr[i+1] && s.ind[k] <= i; k++ {
+			l.ind = append(l.ind, s.ind[k])
+			l.val = append(l.val, s.val[k])
+		}
+		if len(l.ind) == l.ptr[i] || l.ind[len(l.ind)-1] != i {
+			return nil, ErrNotSPD // zero diagonal element
+		}
+		l.ptr[i+1] = len(l.ind)
+	}
+
+	for i := 0; i < n; i++ {
+		lo, hi := l.ptr[i], l.ptr[i+1]-1 // hi is the diagonal element
+		for k := lo; k < hi; k++ {
+			// l[i, j] = (s[i, j] - Σ_{c<j} l[i, c]*l[j, c]) / l[j, j]
+			j := l.ind[k]
+			t := l.val[k] - sparseDot(l, i, j, j)
+			l.val[k] = t / l.val[l.ptr[j+1]-1]
+		}
+		var t T
+		for k := lo; k < hi; k++ {
+			t += l.val[k] * l.val[k]
+		}
+		d := l.val[hi] - t
+		if !(d > 0) {
+			return nil, ErrNotSPD
+		}
+		l.val[hi] = sqrt(d)
+	}
+	return &IncompleteCholesky{l}, nil
+}
+
+// sparseDot returns the dot product of rows i and j of s, restricted
+// to the columns before c.
+func sparseDot(s *CSR, i, j, c int) T {
+	var t T
+	p, q := s.ptr[i], s.ptr[j]
+	for p < s.ptr[i+1] && q < s.ptr[j+1] && s.ind[p] < c && s.ind[q] < c {
+		switch {
+		case s.ind[p] < s.ind[q]:
+			p++
+		case s.ind[p] > s.ind[q]:
+			q++
+		default:
+			t += s.val[p] * s.val[q]
+			p++
+			q++
+		}
+	}
+	return t
+}
+
+// Precondition solves l*lᵀ*z = r by forward and back substitution.
+func (p *IncompleteCholesky) Precondition(r *Vector) *Vector {
+	l := p.l
+	n := l.len[0]
+	z := r.Copy()
+	for i := 0; i < n; i++ {
+		d := l.ptr[i+1] - 1
+		t := z[i]
+		for k := l.ptr[i]; k < d; k++ {
+			t -= l.val[k] * z[l.ind[k]]
+		}
+		z[i] = t / l.val[d]
+	}
+	for i := n - 1; i >= 0; i-- {
+		d := l.ptr[i+1] - 1
+		z[i] /= l.val[d]
+		for k := l.ptr[i]; k < d; k++ {
+			z[l.ind[k]] -= l.val[k] * z[i]
+		}
+	}
+	return z
+}
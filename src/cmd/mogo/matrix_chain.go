@@ -0,0 +1,63 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// MulChain returns the product ms[0] * ms[1] * ... * ms[len(ms)-1].
+// Since matrix multiplication is associative, the product may be
+// evaluated in any order; MulChain chooses the order requiring the
+// fewest scalar multiplications by dynamic programming in O(len(ms)³)
+// time, which can be orders of magnitude faster than evaluating
+// the chain from left to right. If there is only one matrix, it is
+// returned as is.
+func MulChain(ms ...*Matrix) *Matrix {
+	k := len(ms)
+	if k == 0 {
+		panic("empty matrix chain")
+	}
+	// ms[i] is dims[i]×dims[i+1]
+	dims := make([]int, k+1)
+	dims[0], _ = ms[0].Len()
+	for i, m := range ms {
+		r, c := m.Len()
+		if r != dims[i] {
+			panic("incompatible matrix sizes")
+		}
+		dims[i+1] = c
+	}
+
+	// cost[i][j] is the minimal cost of computing ms[i]*...*ms[j],
+	// and split[i][j] the index s of the last multiplication
+	// (ms[i]*...*ms[s]) * (ms[s+1]*...*ms[j]) achieving it
+	cost := make([][]int, k)
+	split := make([][]int, k)
+	for i := range cost {
+		cost[i] = make([]int, k)
+		split[i] = make([]int, k)
+	}
+	for l := 1; l < k; l++ {
+		for i := 0; i+l < k; i++ {
+			j := i + l
+			cost[i][j] = -1
+			for s := i; s < j; s++ {
+				c := cost[i][s] + cost[s+1][j] + dims[i]*dims[s+1]*dims[j+1]
+				if cost[i][j] < 0 || c < cost[i][j] {
+					cost[i][j] = c
+					split[i][j] = s
+				}
+			}
+		}
+	}
+	return mulChain(ms, split, 0, k-1)
+}
+
+func mulChain(ms []*Matrix, split [][]int, i, j int) *Matrix {
+	if i == j {
+		return ms[i]
+	}
+	s := split[i][j]
+	return mulChain(ms, split, i, s) * mulChain(ms, split, s+1, j)
+}
@@ -0,0 +1,165 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// A Checkpoint is a snapshot of the state of an iterative algorithm
+// after Iter iterations. The meaning of the State matrices depends on
+// the algorithm that produced them; each algorithm accepting a
+// Checkpoint to resume from documents its state.
+type Checkpoint struct {
+	Iter  int
+	State []*Matrix
+}
+
+// A Checkpointer persists the state of a long-running computation.
+// Algorithms supporting checkpoints call Checkpoint after every
+// iteration; an error aborts the computation and is returned to the
+// caller. The Checkpointer must not retain c or its matrices after
+// returning.
+type Checkpointer interface {
+	Checkpoint(c *Checkpoint) error
+}
+
+// A FileCheckpointer writes every Every'th checkpoint (every one, if
+// Every <= 0) to the file Path, replacing the previous one atomically,
+// so that the file always holds a complete checkpoint that ResumeFrom
+// can read.
+type FileCheckpointer struct {
+	Path  string
+	Every int
+}
+
+// Checkpoint file format: the 4-byte magic "GOCK", the iteration
+// number as little-endian uint64, the number of state matrices as
+// little-endian uint32, and the matrices in the binary matrix format.
+const checkpointMagic = "GOCK"
+
+func (f *FileCheckpointer) Checkpoint(c *Checkpoint) error {
+	if f.Every > 0 && c.Iter%f.Every != 0 {
+		return nil
+	}
+	tmp := f.Path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := writeCheckpoint(file, c); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, f.Path)
+}
+
+func writeCheckpoint(w io.Writer, c *Checkpoint) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(checkpointMagic)
+	binary.Write(bw, binary.LittleEndian, uint64(c.Iter))
+	binary.Write(bw, binary.LittleEndian, uint32(len(c.State)))
+	for _, a := range c.State {
+		if err := a.Save(bw); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ResumeFrom reads the checkpoint stored in the named file by a
+// FileCheckpointer, for passing to the algorithm that wrote it.
+func ResumeFrom(path string) (*Checkpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// Load reads through br without buffering ahead of it,
+	// so that matrices can be read one after the other.
+	br := bufio.NewReader(f)
+	var magic [len(checkpointMagic)]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil {
+		return nil, err
+	}
+	if string(magic[:]) != checkpointMagic {
+		return nil, errFormat
+	}
+	var iter uint64
+	var count uint32
+	if err := binary.Read(br, binary.LittleEndian, &iter); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(br, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+	c := &Checkpoint{Iter: int(iter)}
+	for i := uint32(0); i < count; i++ {
+		a, err := Load(br)
+		if err != nil {
+			return nil, err
+		}
+		c.State = append(c.State, a)
+	}
+	return c, nil
+}
+
+// checkpointState checks that c holds count state matrices, as
+// expected by the algorithm resuming from it.
+func checkpointState(c *Checkpoint, count int) []*Matrix {
+	if len(c.State) != count {
+		panic("invalid checkpoint")
+	}
+	return c.State
+}
+
+// checkpointVector returns the n×1 state matrix a as a vector.
+func checkpointVector(a *Matrix, n int) *Vector {
+	if r, c := a.Len(); r != n || c != 1 {
+		panic("invalid checkpoint")
+	}
+	return a.Col(0)
+}
+
+// checkpointMatrix checks that the state matrix a is n×m.
+func checkpointMatrix(a *Matrix, n, m int) *Matrix {
+	if r, c := a.Len(); r != n || c != m {
+		panic("invalid checkpoint")
+	}
+	return a
+}
+
+// scalarState returns the scalars s as an n×1 state matrix.
+func scalarState(s ...T) *Matrix {
+	a := NewMatrix(len(s), 1)
+	for i, x := range s {
+		a[i, 0] = x
+	}
+	return a
+}
+
+// checkpointScalars sets the variables p to the elements of the state
+// matrix a written by scalarState.
+func checkpointScalars(a *Matrix, p ...*T) {
+	checkpointMatrix(a, len(p), 1)
+	for i, x := range p {
+		*x = a[i, 0]
+	}
+}
+
+// colMatrix returns the n×1 matrix view of the vector x.
+func colMatrix(x *Vector) *Matrix {
+	return &Matrix{x.array, x.off, Shape{x.len, 1}, Shape{x.stride, 1}}
+}
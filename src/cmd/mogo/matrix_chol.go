@@ -0,0 +1,274 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "errors"
+
+// ErrNotSPD is returned by Cholesky for matrices that are not
+// (numerically) symmetric positive definite.
+var ErrNotSPD = errors.New("matrix is not positive definite")
+
+// A Cholesky is the Cholesky factorization A = L*Lᵀ of a symmetric
+// positive definite matrix A, where L is lower triangular with a
+// positive diagonal.
+type Cholesky struct {
+	l     *Matrix // lower triangular; zero above the diagonal
+	anorm T       // 1-norm of A
+}
+
+// Cholesky computes the Cholesky factorization of the symmetric
+// positive definite matrix a. Only the lower triangle of a is
+// accessed. If a is not positive definite, the result is ErrNotSPD.
+func (a *Matrix) Cholesky() (*Cholesky, error) {
+	return a.CholeskyWith(nil)
+}
+
+// CholeskyWith is like Cholesky, but the factorization is stored in
+// memory borrowed from w (see Workspace).
+func (a *Matrix) CholeskyWith(w *Workspace) (*Cholesky, error) {
+	MustBeSquare("Cholesky", a)
+	n, _ := a.Len()
+	l := w.matrix(n, n)
+	for j := 0; j < n; j++ {
+		d := a[j, j]
+		for k := 0; k < j; k++ {
+			d -= l[j, k] * l[j, k]
+		}
+		if !(d > 0) {
+			return nil, ErrNotSPD
+		}
+		d = sqrt(d)
+		l[j, j] = d
+		for i := j + 1; i < n; i++ {
+			t := a[i, j]
+			for k := 0; k < j; k++ {
+				t -= l[i, k] * l[j, k]
+			}
+			l[i, j] = t / d
+		}
+	}
+	return &Cholesky{l, symNorm1(a)}, nil
+}
+
+// symNorm1 returns the 1-norm of the symmetric matrix whose lower
+// triangle is that of a.
+func symNorm1(a *Matrix) T {
+	n, _ := a.Len()
+	var max T
+	for j := 0; j < n; j++ {
+		var s T
+		for i := 0; i < n; i++ {
+			if i >= j {
+				s += abs(a[i, j])
+			} else {
+				s += abs(a[j, i])
+			}
+		}
+		if s > max {
+			max = s
+		}
+	}
+	return max
+}
+
+// L returns the lower triangular factor L of A = L*Lᵀ.
+func (f *Cholesky) L() *Matrix { return f.l.Copy() }
+
+// SolveVec returns the solution x of A*x = b for the factorized
+// matrix A.
+func (f *Cholesky) SolveVec(b *Vector) *Vector {
+	return f.SolveVecTo(NewVector(b.Len()), b)
+}
+
+// SolveVecTo stores the solution x of A*x = b in dst and returns dst,
+// without allocating. dst may be b.
+func (f *Cholesky) SolveVecTo(dst, b *Vector) *Vector {
+	if n, _ := f.l.Len(); b.Len() != n || dst.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	copyVector(dst, b)
+	f.solve(dst)
+	return dst
+}
+
+// SolveMat returns the solution X of A*X = B for the factorized
+// matrix A.
+func (f *Cholesky) SolveMat(b *Matrix) *Matrix {
+	n, m := b.Len()
+	if k, _ := f.l.Len(); n != k {
+		panic("incompatible matrix sizes")
+	}
+	x := b.Copy()
+	for j := 0; j < m; j++ {
+		f.solve(x.Col(j))
+	}
+	return x
+}
+
+// solve overwrites x with the solution y of A*y = x.
+func (f *Cholesky) solve(x *Vector) {
+	f.l.solveTriangular(x, false, false)
+	lt := Matrix{f.l.array, f.l.off, f.l.len.transpose(), f.l.stride.transpose()}
+	lt.solveTriangular(x, true, false)
+}
+
+// RCond returns an estimate of the reciprocal of the 1-norm condition
+// number of the factorized matrix (see LU.RCond). After Update or
+// Downdate, it uses an upper bound of ‖A‖₁ and so may underestimate.
+func (f *Cholesky) RCond() T {
+	n, _ := f.l.Len()
+	return rcond(n, f.anorm, f.solve, f.solve)
+}
+
+// Update modifies the factorization in place to be that of A + x*xᵀ,
+// in O(n²) time instead of the O(n³) of a new factorization, as needed
+// by recursive least squares and similar filters adding one
+// observation at a time.
+func (f *Cholesky) Update(x *Vector) {
+	f.rankOne(x, 1)
+}
+
+// Downdate modifies the factorization in place to be that of A - x*xᵀ,
+// in O(n²) time. If A - x*xᵀ is not positive definite, the result is
+// ErrNotSPD and the factorization is unchanged.
+func (f *Cholesky) Downdate(x *Vector) error {
+	n, _ := f.l.Len()
+	if x.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	// A - x*xᵀ = L*(I - p*pᵀ)*Lᵀ with p = L⁻¹*x is positive definite
+	// if and only if ‖p‖₂ < 1
+	p := x.Copy()
+	f.l.solveTriangular(p, false, false)
+	if !(p*p < 1) {
+		return ErrNotSPD
+	}
+	f.rankOne(x, -1)
+	return nil
+}
+
+// rankOne updates (sign > 0) or downdates (sign < 0) the factor L by
+// x, applying a sequence of ordinary or hyperbolic rotations.
+func (f *Cholesky) rankOne(x *Vector, sign T) {
+	l := f.l
+	n, _ := l.Len()
+	if x.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	w := x.Copy()
+	for k := 0; k < n; k++ {
+		lkk := l[k, k]
+		r := sqrt(lkk*lkk + sign*w[k]*w[k])
+		c, s := r/lkk, w[k]/lkk
+		l[k, k] = r
+		for i := k + 1; i < n; i++ {
+			lik := (l[i, k] + sign*s*w[i]) / c
+			l[i, k] = lik
+			w[i] = c*w[i] - s*lik
+		}
+	}
+	// ‖A ± x*xᵀ‖₁ <= ‖A‖₁ + ‖x‖₁*‖x‖∞; the exact norm would take O(n³)
+	// time, so RCond uses this bound after updates
+	f.anorm += vecNorm1(x) * vecNormInf(x)
+}
+
+// A PivotedCholesky is the Cholesky factorization with complete
+// (diagonal) pivoting Pᵀ*A*P = L*Lᵀ of a symmetric positive
+// semidefinite matrix A, where P is a permutation matrix and L is
+// n×r lower trapezoidal, with r the numerical rank of A.
+type PivotedCholesky struct {
+	l   *Matrix // n×rank lower trapezoidal
+	piv []int   // column i of A*P is column piv[i] of A
+}
+
+// CholeskyPivoted computes the pivoted Cholesky factorization of the
+// symmetric positive semidefinite matrix a, choosing the largest
+// remaining diagonal element as pivot in each step. The factorization
+// stops once all remaining diagonal elements are <= tol; the number of
+// steps taken is the numerical rank. If tol < 0, n * ε * max(diag(a))
+// is used. Only the lower triangle of a is accessed.
+func (a *Matrix) CholeskyPivoted(tol T) *PivotedCholesky {
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	w := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			w[i, j] = a[i, j]
+			w[j, i] = a[i, j]
+		}
+	}
+	piv := make([]int, n)
+	for i := range piv {
+		piv[i] = i
+	}
+
+	rank := n
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if w[i, i] > w[p, p] {
+				p = i
+			}
+		}
+		if k == 0 && tol < 0 {
+			tol = T(n) * epsilon * w[p, p]
+		}
+		if !(w[p, p] > tol) {
+			rank = k
+			break
+		}
+		if p != k {
+			// symmetric permutation: swap rows and columns k and p
+			for j := 0; j < n; j++ {
+				t := w[k, j]
+				w[k, j] = w[p, j]
+				w[p, j] = t
+			}
+			for i := 0; i < n; i++ {
+				t := w[i, k]
+				w[i, k] = w[i, p]
+				w[i, p] = t
+			}
+			piv[k], piv[p] = piv[p], piv[k]
+		}
+
+		d := sqrt(w[k, k])
+		w[k, k] = d
+		for i := k + 1; i < n; i++ {
+			w[i, k] /= d
+			w[k, i] = 0
+		}
+		for j := k + 1; j < n; j++ {
+			for i := j; i < n; i++ {
+				w[i, j] -= w[i, k] * w[j, k]
+				w[j, i] = w[i, j]
+			}
+		}
+	}
+
+	l := NewMatrix(n, rank)
+	for i := 0; i < n; i++ {
+		for j := 0; j < rank && j <= i; j++ {
+			l[i, j] = w[i, j]
+		}
+	}
+	return &PivotedCholesky{l, piv}
+}
+
+// Rank returns the numerical rank r of the factorized matrix.
+func (f *PivotedCholesky) Rank() int {
+	_, r := f.l.Len()
+	return r
+}
+
+// L returns the n×r lower trapezoidal factor L.
+func (f *PivotedCholesky) L() *Matrix { return f.l }
+
+// Piv returns the permutation P: column i of A*P is column Piv()[i] of A.
+func (f *PivotedCholesky) Piv() []int { return f.piv }
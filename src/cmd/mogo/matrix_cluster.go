@@ -0,0 +1,270 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// SpectralCluster partitions the vertices of the weighted undirected
+// graph with symmetric affinity (adjacency) matrix a into k clusters
+// and returns the cluster label, from 0 to k-1, of each vertex.
+//
+// It uses the normalized spectral clustering of Ng, Jordan, and Weiss:
+// the rows of the matrix of eigenvectors belonging to the k smallest
+// eigenvalues of the normalized Laplacian I - D^-½·a·D^-½ (D being the
+// diagonal matrix of vertex degrees) are normalized to unit length
+// and clustered with k-means. The eigenvectors are computed by
+// EigsSparse; if they do not converge, the labels are computed from
+// the current approximation and the error is ErrNoConvergence. The
+// result is deterministic.
+func (a *CSR) SpectralCluster(k int) ([]int, error) {
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	if k < 1 || k > n {
+		panic("invalid number of clusters")
+	}
+
+	// the smallest eigenvalues of the normalized Laplacian (which lie
+	// in [0, 2]) are the largest of the positive semidefinite operator
+	// I + D^-½·a·D^-½
+	s := make([]T, n)
+	for i := 0; i < n; i++ {
+		var d T
+		for p := a.ptr[i]; p < a.ptr[i+1]; p++ {
+			d += a.val[p]
+		}
+		if d > 0 {
+			s[i] = 1 / sqrt(d)
+		}
+	}
+	_, y, err := EigsSparse(&normalizedAffinity{a, s}, k, WhichLargest)
+	if y == nil {
+		return nil, err
+	}
+
+	for i := 0; i < n; i++ {
+		row := y.Row(i)
+		if norm := sqrt(row * row); norm > 0 {
+			for j := 0; j < k; j++ {
+				row[j] /= norm
+			}
+		}
+	}
+	labels, _, _ := y.KMeans(k, rand.New(rand.NewSource(1)), nil)
+	return labels, err
+}
+
+// normalizedAffinity is the operator I + D^-½·a·D^-½, with s the
+// diagonal of D^-½.
+type normalizedAffinity struct {
+	a *CSR
+	s []T
+}
+
+func (op *normalizedAffinity) Len() (int, int) { return op.a.Len() }
+
+func (op *normalizedAffinity) MulVec(x *Vector) *Vector {
+	a, s := op.a, op.s
+	y := NewVector(len(s))
+	for i := range s {
+		var t T
+		for p := a.ptr[i]; p < a.ptr[i+1]; p++ {
+			t += a.val[p] * s[a.ind[p]] * x[a.ind[p]]
+		}
+		y[i] = x[i] + s[i]*t
+	}
+	return y
+}
+
+// orthonormalize orthonormalizes the columns of q in place with
+// (twice applied) modified Gram-Schmidt. Columns that are linearly
+// dependent on the previous ones are replaced by random vectors
+// drawn from r.
+func orthonormalize(q *Matrix, r *rand.Rand) {
+	n, k := q.Len()
+	for j := 0; j < k; j++ {
+		qj := q.Col(j)
+		for {
+			before := sqrt(qj * qj)
+			for pass := 0; pass < 2; pass++ {
+				for l := 0; l < j; l++ {
+					ql := q.Col(l)
+					h := ql * qj
+					for i := 0; i < n; i++ {
+						qj[i] -= h * ql[i]
+					}
+				}
+			}
+			norm := sqrt(qj * qj)
+			if norm > 1e-10*before {
+				for i := 0; i < n; i++ {
+					qj[i] /= norm
+				}
+				break
+			}
+			for i := 0; i < n; i++ {
+				qj[i] = T(r.NormFloat64())
+			}
+		}
+	}
+}
+
+// KMeansOptions controls KMeans.
+// A nil *KMeansOptions is equivalent to the zero value.
+type KMeansOptions struct {
+	// MaxIter is the maximum number of Lloyd iterations; 0 means 300.
+	MaxIter int
+	// Checkpointer, if not nil, is called after every iteration with
+	// the k×m matrix of centers and the labels (as an n×1 matrix)
+	// as state.
+	Checkpointer Checkpointer
+	// Resume, if not nil, is a checkpoint of a previous run for the
+	// same data to continue from, instead of a k-means++ start.
+	Resume *Checkpoint
+}
+
+// KMeans partitions the rows of x into k clusters with Lloyd's
+// algorithm, seeded with k-means++ using r as the source of
+// randomness. It returns the cluster label of each row and the k×m
+// matrix of cluster centers. An error is only returned by a
+// Checkpointer.
+func (x *Matrix) KMeans(k int, r *rand.Rand, opts *KMeansOptions) (labels []int, centers *Matrix, err error) {
+	if opts == nil {
+		opts = new(KMeansOptions)
+	}
+	n, m := x.Len()
+	if k < 1 || k > n {
+		panic("invalid number of clusters")
+	}
+	maxIter := opts.MaxIter
+	if maxIter <= 0 {
+		maxIter = 300
+	}
+
+	labels = make([]int, n)
+	iter := 0
+	if c := opts.Resume; c != nil {
+		s := checkpointState(c, 2)
+		centers = checkpointMatrix(s[0], k, m)
+		l := checkpointVector(s[1], n)
+		for i := range labels {
+			labels[i] = int(l[i])
+		}
+		iter = c.Iter
+	} else {
+		centers = kmeansPlusPlus(x, k, r)
+		for i := range labels {
+			labels[i] = -1
+		}
+	}
+
+	count := make([]int, k)
+	for ; iter < maxIter; iter++ {
+		changed := false
+		for i := 0; i < n; i++ {
+			best, bestDist := 0, T(math.Inf(1))
+			for l := 0; l < k; l++ {
+				if di := sqDist(x.Row(i), centers.Row(l)); di < bestDist {
+					best, bestDist = l, di
+				}
+			}
+			if labels[i] != best {
+				labels[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		// recompute centers; empty clusters keep their center
+		for l := range count {
+			count[l] = 0
+		}
+		for i := 0; i < n; i++ {
+			count[labels[i]]++
+		}
+		for l := 0; l < k; l++ {
+			if count[l] > 0 {
+				row := centers.Row(l)
+				for j := 0; j < m; j++ {
+					row[j] = 0
+				}
+			}
+		}
+		for i := 0; i < n; i++ {
+			row, xi := centers.Row(labels[i]), x.Row(i)
+			for j := 0; j < m; j++ {
+				row[j] += xi[j] / T(count[labels[i]])
+			}
+		}
+
+		if cp := opts.Checkpointer; cp != nil {
+			l := NewVector(n)
+			for i := range labels {
+				l[i] = T(labels[i])
+			}
+			if err := cp.Checkpoint(&Checkpoint{iter + 1, []*Matrix{centers, colMatrix(l)}}); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return labels, centers, nil
+}
+
+// kmeansPlusPlus returns k initial centers for k-means chosen among
+// the rows of x: each further center is chosen with probability
+// proportional to the squared distance to the nearest center so far.
+func kmeansPlusPlus(x *Matrix, k int, r *rand.Rand) *Matrix {
+	n, m := x.Len()
+	centers := NewMatrix(k, m)
+	d := make([]T, n)
+	for i := range d {
+		d[i] = T(math.Inf(1))
+	}
+	c := r.Intn(n)
+	for l := 0; l < k; l++ {
+		copyVector(centers.Row(l), x.Row(c))
+		var sum T
+		for i := 0; i < n; i++ {
+			if di := sqDist(x.Row(i), centers.Row(l)); di < d[i] {
+				d[i] = di
+			}
+			sum += d[i]
+		}
+		if sum == 0 {
+			c = r.Intn(n)
+			continue
+		}
+		t := T(r.Float64()) * sum
+		for c = 0; c < n-1; c++ {
+			if t -= d[c]; t < 0 {
+				break
+			}
+		}
+	}
+	return centers
+}
+
+func sqDist(x, y *Vector) T {
+	var t T
+	for i := 0; i < x.Len(); i++ {
+		d := x[i] - y[i]
+		t += d * d
+	}
+	return t
+}
+
+func copyVector(dst, src *Vector) {
+	for i := 0; i < src.Len(); i++ {
+		dst[i] = src[i]
+	}
+}
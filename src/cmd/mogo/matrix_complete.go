@@ -0,0 +1,185 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"math"
+	"math/rand"
+)
+
+// ALSOptions controls matrix completion with CompleteALS.
+// A nil *ALSOptions is equivalent to the zero value.
+type ALSOptions struct {
+	// Observed marks the known entries of the matrix: a[i, j] is
+	// observed if Observed[i, j] != 0. If Observed is nil, all
+	// entries that are not NaN are observed.
+	Observed *Matrix
+	// Lambda is the weight of the L2 regularization of the factors;
+	// 0 means 0.01. Regularization keeps the least-squares problems
+	// well-posed for rows or columns with fewer than k observations.
+	Lambda T
+	// Iters is the maximum number of alternating iterations; 0 means 50.
+	Iters int
+	// Checkpointer, if not nil, is called after every iteration with
+	// the factors w and hᵀ as state.
+	Checkpointer Checkpointer
+	// Resume, if not nil, is a checkpoint of a previous run for the
+	// same matrix to continue from, instead of a random start.
+	Resume *Checkpoint
+	// Rand is the source of randomness for the random start; nil
+	// means a source with a fixed seed, so that runs are reproducible.
+	Rand *rand.Rand
+}
+
+// CompleteALS fits a rank-k model w*h to the observed entries of the
+// n×m matrix a by alternating least squares, minimizing
+//
+//	Σ_observed (a[i, j] - (w*h)[i, j])² + λ(|w|² + |h|²)
+//
+// and returns the factors w (n×k) and h (k×m); w*h estimates the
+// missing entries. Each half-step solves an independent regularized
+// k×k least-squares problem per row of w (or column of h), which are
+// processed in parallel. The iteration stops early once the objective
+// no longer decreases noticeably.
+func (a *Matrix) CompleteALS(k int, opts *ALSOptions) (w, h *Matrix, err error) {
+	if opts == nil {
+		opts = new(ALSOptions)
+	}
+	n, m := a.Len()
+	if k < 1 {
+		panic("invalid rank")
+	}
+	observed := opts.Observed
+	if observed == nil {
+		observed = NewMatrix(n, m)
+		for i := 0; i < n; i++ {
+			for j := 0; j < m; j++ {
+				if x := a[i, j]; x == x {
+					observed[i, j] = 1
+				}
+			}
+		}
+	} else if on, om := observed.Len(); on != n || om != m {
+		panic("incompatible matrix sizes")
+	}
+	lambda, iters := opts.Lambda, opts.Iters
+	if lambda <= 0 {
+		lambda = 0.01
+	}
+	if iters <= 0 {
+		iters = 50
+	}
+
+	rnd := opts.Rand
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(1))
+	}
+	// ht holds the transpose of h so that both half-steps update rows
+	w, ht := NewMatrix(n, k), NewMatrix(m, k)
+	for i := 0; i < m; i++ {
+		for j := 0; j < k; j++ {
+			ht[i, j] = T(rnd.NormFloat64())
+		}
+	}
+	prev := T(math.Inf(1))
+	it := 0
+	if c := opts.Resume; c != nil {
+		s := checkpointState(c, 2)
+		w, ht = checkpointMatrix(s[0], n, k), checkpointMatrix(s[1], m, k)
+		prev = alsObjective(a, observed, w, ht, lambda)
+		it = c.Iter
+	}
+	for ; it < iters; it++ {
+		if err := alsStep(a, observed, ht, w, lambda); err != nil {
+			return nil, nil, err
+		}
+		if err := alsStep(a.Transpose(), observed.Transpose(), w, ht, lambda); err != nil {
+			return nil, nil, err
+		}
+		if cp := opts.Checkpointer; cp != nil {
+			if err := cp.Checkpoint(&Checkpoint{it + 1, []*Matrix{w, ht}}); err != nil {
+				return nil, nil, err
+			}
+		}
+		obj := alsObjective(a, observed, w, ht, lambda)
+		if prev-obj <= 1e-9*obj {
+			break
+		}
+		prev = obj
+	}
+	return w, ht.TransposeCopy(), nil
+}
+
+// alsStep overwrites each row x.Row(i) with the solution of the
+// regularized least-squares problem
+//
+//	min Σ_{j observed in row i} (a[i, j] - f.Row(j)*x.Row(i))² + λ|x.Row(i)|²
+func alsStep(a, observed, f, x *Matrix, lambda T) error {
+	n, m := a.Len()
+	_, k := f.Len()
+	errs := make([]error, n)
+	parallelFor(context.Background(), n, rowGrain(n), func(lo, hi int) {
+		g := NewMatrix(k, k)
+		for i := lo; i < hi; i++ {
+			for p := 0; p < k; p++ {
+				for q := 0; q <= p; q++ {
+					g[p, q] = 0
+				}
+				g[p, p] = lambda
+			}
+			r := NewVector(k)
+			for j := 0; j < m; j++ {
+				if observed[i, j] == 0 {
+					continue
+				}
+				fj, aij := f.Row(j), a[i, j]
+				for p := 0; p < k; p++ {
+					for q := 0; q <= p; q++ {
+						g[p, q] += fj[p] * fj[q]
+					}
+					r[p] += aij * fj[p]
+				}
+			}
+			c, err := g.Cholesky()
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			c.solve(r)
+			copyVector(x.Row(i), r)
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alsObjective returns the value of the objective minimized by
+// CompleteALS for the factors w and hᵀ = ht.
+func alsObjective(a, observed, w, ht *Matrix, lambda T) T {
+	n, m := a.Len()
+	var t T
+	for i := 0; i < n; i++ {
+		wi := w.Row(i)
+		for j := 0; j < m; j++ {
+			if observed[i, j] != 0 {
+				d := a[i, j] - wi*ht.Row(j)
+				t += d * d
+			}
+		}
+		t += lambda * (wi * wi)
+	}
+	for j := 0; j < m; j++ {
+		hj := ht.Row(j)
+		t += lambda * (hj * hj)
+	}
+	return t
+}
@@ -0,0 +1,253 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// A CMatrix is a matrix with complex elements. Like Matrix, it may be
+// a view with arbitrary strides into a shared array.
+type CMatrix struct {
+	array       []complex128
+	off         int // index of m[0, 0] in array
+	len, stride Shape
+}
+
+func NewCMatrix(n, m int) *CMatrix {
+	if n < 0 || m < 0 {
+		panic("invalid length")
+	}
+	return &CMatrix{
+		array:  make([]complex128, n*m),
+		len:    Shape{n, m},
+		stride: Shape{m, 1}, // row-major
+	}
+}
+
+func (m *CMatrix) addr(i, j int) *complex128 {
+	if boundsChecks && (uint(i) >= uint(m.len[0]) || uint(j) >= uint(m.len[1])) {
+		panic("index out of bounds")
+	}
+	return &m.array[m.off+i*m.stride[0]+j*m.stride[1]]
+}
+
+func (m *CMatrix) Len() (int, int)             { return m.len[0], m.len[1] }
+func (m *CMatrix) [] (i, j int) complex128     { return *m.addr(i, j) }
+func (m *CMatrix) []= (i, j int, x complex128) { *m.addr(i, j) = x }
+
+func (m *CMatrix) Row(i int) *CVector {
+	return &CVector{m.array, m.off + i*m.stride[0], m.len[1], m.stride[1]}
+}
+func (m *CMatrix) Col(j int) *CVector {
+	return &CVector{m.array, m.off + j*m.stride[1], m.len[0], m.stride[0]}
+}
+
+// Transpose returns the transpose of a as a view sharing a's storage.
+// The elements are not conjugated; see ConjTranspose.
+func (a *CMatrix) Transpose() *CMatrix {
+	return &CMatrix{a.array, a.off, a.len.transpose(), a.stride.transpose()}
+}
+
+// ConjTranspose returns the conjugate (Hermitian) transpose aᴴ of a,
+// with aᴴ[i, j] = conj(a[j, i]), in new storage.
+func (a *CMatrix) ConjTranspose() *CMatrix {
+	n, m := a.Len()
+	c := NewCMatrix(m, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[j, i] = conj(a[i, j])
+		}
+	}
+	return c
+}
+
+// Conj returns the element-wise complex conjugate of a.
+func (a *CMatrix) Conj() *CMatrix {
+	n, m := a.Len()
+	c := NewCMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[i, j] = conj(a[i, j])
+		}
+	}
+	return c
+}
+
+// IsHermitian reports whether a is square and a[i, j] equals
+// conj(a[j, i]) for all i, j; in particular, the diagonal must be
+// real. Elements are compared with the absolute tolerance tol, as in
+// IsSymmetric.
+func (a *CMatrix) IsHermitian(tol T) bool {
+	n, m := a.Len()
+	if n != m {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			if !(T(cmplx.Abs(a[i, j]-conj(a[j, i]))) <= tol) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Matrix product.
+func (a *CMatrix) * (b *CMatrix) *CMatrix {
+	n, m := a.Len()
+	o, p := b.Len()
+	if m != o {
+		panic("incompatible matrix sizes")
+	}
+	c := NewCMatrix(n, p)
+	for i := 0; i < n; i++ {
+		for j := 0; j < p; j++ {
+			var t complex128
+			for k := 0; k < m; k++ {
+				t += a[i, k] * b[k, j]
+			}
+			c[i, j] = t
+		}
+	}
+	return c
+}
+
+// MulVec returns the matrix-vector product a*x.
+func (a *CMatrix) MulVec(x *CVector) *CVector {
+	n, m := a.Len()
+	if x.Len() != m {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewCVector(n)
+	for i := 0; i < n; i++ {
+		var t complex128
+		for j := 0; j < m; j++ {
+			t += a[i, j] * x[j]
+		}
+		y[i] = t
+	}
+	return y
+}
+
+// Copy returns a copy of a with its own (row-major) storage.
+func (a *CMatrix) Copy() *CMatrix {
+	n, m := a.Len()
+	c := NewCMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[i, j] = a[i, j]
+		}
+	}
+	return c
+}
+
+// Complex returns a as a complex matrix with zero imaginary parts.
+func (a *Matrix) Complex() *CMatrix {
+	n, m := a.Len()
+	c := NewCMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[i, j] = complex(float64(a[i, j]), 0)
+		}
+	}
+	return c
+}
+
+// Real returns the matrix of the real parts of the elements of a.
+func (a *CMatrix) Real() *Matrix {
+	n, m := a.Len()
+	c := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[i, j] = T(real(a[i, j]))
+		}
+	}
+	return c
+}
+
+// Imag returns the matrix of the imaginary parts of the elements of a.
+func (a *CMatrix) Imag() *Matrix {
+	n, m := a.Len()
+	c := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[i, j] = T(imag(a[i, j]))
+		}
+	}
+	return c
+}
+
+// A CVector is a vector with complex elements, possibly a strided view
+// such as a row or column of a CMatrix.
+type CVector struct {
+	array       []complex128
+	off         int // index of x[0] in array
+	len, stride int
+}
+
+func NewCVector(n int) *CVector {
+	if n < 0 {
+		panic("invalid length")
+	}
+	return &CVector{make([]complex128, n), 0, n, 1}
+}
+
+func (x *CVector) addr(i int) *complex128 {
+	if boundsChecks && uint(i) >= uint(x.len) {
+		panic("index out of bounds")
+	}
+	return &x.array[x.off+i*x.stride]
+}
+
+func (x *CVector) Len() int                 { return x.len }
+func (x *CVector) [] (i int) complex128     { return *x.addr(i) }
+func (x *CVector) []= (i int, z complex128) { *x.addr(i) = z }
+
+// dot-product xᴴ*y, conjugating the left operand, so that x*x is the
+// (real, non-negative) squared norm of x
+func (x *CVector) * (y *CVector) complex128 {
+	if x.Len() != y.Len() {
+		panic("incompatible vector lengths")
+	}
+	var t complex128
+	for i := x.Len() - 1; i >= 0; i-- {
+		t += conj(x[i]) * y[i]
+	}
+	return t
+}
+
+// DotU returns the unconjugated dot product xᵀ*y.
+func (x *CVector) DotU(y *CVector) complex128 {
+	if x.Len() != y.Len() {
+		panic("incompatible vector lengths")
+	}
+	var t complex128
+	for i := x.Len() - 1; i >= 0; i-- {
+		t += x[i] * y[i]
+	}
+	return t
+}
+
+// Norm returns the Euclidean norm of x, scaled to avoid overflow.
+func (x *CVector) Norm() T {
+	var t T
+	for i := 0; i < x.Len(); i++ {
+		t = T(math.Hypot(float64(t), cmplx.Abs(x[i])))
+	}
+	return t
+}
+
+// Copy returns a copy of x with its own (contiguous) storage.
+func (x *CVector) Copy() *CVector {
+	y := NewCVector(x.len)
+	for i := 0; i < x.len; i++ {
+		y[i] = x[i]
+	}
+	return y
+}
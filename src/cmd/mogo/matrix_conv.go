@@ -0,0 +1,309 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A CorrelateMode selects the size of the result of Correlate2D.
+type CorrelateMode int
+
+const (
+	CorrelateFull  CorrelateMode = iota // every position where kernel and input overlap
+	CorrelateSame                       // same size as the input, kernel centered
+	CorrelateValid                      // every position where the kernel lies fully inside the input
+)
+
+// A Padding determines the values of elements outside a matrix seen
+// by windowed operations. Padding is virtual: out-of-range indices are
+// mapped back into the matrix and no padded copy is created.
+type Padding int
+
+const (
+	PadZero      Padding = iota // zero outside the matrix
+	PadReflect                  // mirrored at the edge, without repeating it (dcb|abcd|cba)
+	PadReplicate                // edge elements repeated (aaa|abcd|ddd)
+	PadWrap                     // periodic continuation (bcd|abcd|abc)
+)
+
+// index maps the (possibly out-of-range) index i for a dimension of
+// length n > 0 into [0, n). The result is false if the element is
+// outside the matrix and zero.
+func (p Padding) index(i, n int) (int, bool) {
+	if 0 <= i && i < n {
+		return i, true
+	}
+	switch p {
+	case PadZero:
+		return 0, false
+	case PadReflect:
+		if n == 1 {
+			return 0, true
+		}
+		period := 2 * (n - 1)
+		if i = mod(i, period); i >= n {
+			i = period - i
+		}
+		return i, true
+	case PadReplicate:
+		if i < 0 {
+			return 0, true
+		}
+		return n - 1, true
+	case PadWrap:
+		return mod(i, n), true
+	}
+	panic("invalid padding")
+}
+
+// mod returns i modulo n in the range [0, n) for n > 0.
+func mod(i, n int) int {
+	if i %= n; i < 0 {
+		i += n
+	}
+	return i
+}
+
+// CorrelateOptions controls the behavior of Correlate2D.
+// A nil *CorrelateOptions is equivalent to the zero value.
+type CorrelateOptions struct {
+	Mode    CorrelateMode
+	Padding Padding // values outside a; used by CorrelateFull and CorrelateSame
+}
+
+// Correlate2D returns the 2D cross-correlation of a with kernel,
+// that is, a convolution without flipping the kernel:
+//
+//	c[i, j] = Σ a[i+u-oi, j+v-oj] * kernel[u, v]
+//
+// where the sum is over all kernel elements (u, v), elements outside
+// a are determined by opts.Padding, and the offset (oi, oj) and the
+// size of c depend on opts.Mode. For CorrelateSame the kernel center is at
+// ((kh-1)/2, (kw-1)/2) for a kh×kw kernel. If a or kernel is empty,
+// the result is all zero: n×m for CorrelateSame and 0×0 otherwise, as
+// kernel and input overlap nowhere.
+func (a *Matrix) Correlate2D(kernel *Matrix, opts *CorrelateOptions) *Matrix {
+	if opts == nil {
+		opts = new(CorrelateOptions)
+	}
+	n, m := a.Len()
+	kh, kw := kernel.Len()
+
+	var cn, cm, oi, oj int
+	switch opts.Mode {
+	case CorrelateFull:
+		cn, cm = n+kh-1, m+kw-1
+		oi, oj = kh-1, kw-1
+	case CorrelateSame:
+		cn, cm = n, m
+		oi, oj = (kh-1)/2, (kw-1)/2
+	case CorrelateValid:
+		cn, cm = n-kh+1, m-kw+1
+	default:
+		panic("invalid correlation mode")
+	}
+	if kh == 0 || kw == 0 || n == 0 || m == 0 || cn < 0 || cm < 0 {
+		// no overlap; this also keeps Padding.index from seeing n == 0
+		if opts.Mode == CorrelateSame {
+			return NewMatrix(n, m)
+		}
+		return NewMatrix(0, 0)
+	}
+
+	c := NewMatrix(cn, cm)
+	for i := 0; i < cn; i++ {
+		for j := 0; j < cm; j++ {
+			var t T
+			for u := 0; u < kh; u++ {
+				ii, ok := opts.Padding.index(i+u-oi, n)
+				if !ok {
+					continue
+				}
+				for v := 0; v < kw; v++ {
+					if jj, ok := opts.Padding.index(j+v-oj, m); ok {
+						t += a[ii, jj] * kernel[u, v]
+					}
+				}
+			}
+			c[i, j] = t
+		}
+	}
+	return c
+}
+
+// poolLen returns the number of kh×kw windows with the given stride
+// that fit into an n×m matrix.
+func poolLen(n, m, kh, kw, stride int) (int, int) {
+	if kh <= 0 || kw <= 0 || stride <= 0 {
+		panic("invalid pooling window")
+	}
+	pn, pm := 0, 0
+	if n >= kh {
+		pn = (n-kh)/stride + 1
+	}
+	if m >= kw {
+		pm = (m-kw)/stride + 1
+	}
+	return pn, pm
+}
+
+// PoolOptions controls the behavior of MaxPoolWith and AvgPoolWith.
+// A nil *PoolOptions is equivalent to the zero value.
+type PoolOptions struct {
+	Pad     int     // number of elements added on every side of a
+	Padding Padding // values of the added elements
+}
+
+// MaxPool returns the maximum of each kh×kw window of a, with windows
+// placed stride elements apart in both directions; windows that don't
+// fit into a entirely are dropped. For each element of the result,
+// argmax holds the position i*m + j of the maximum a[i, j] in the
+// n×m matrix a, in row-major order.
+func (a *Matrix) MaxPool(kh, kw, stride int) (p *Matrix, argmax []int) {
+	return a.MaxPoolWith(kh, kw, stride, nil)
+}
+
+// MaxPoolWith is like MaxPool, but places the windows in a padded by
+// opts.Pad elements on every side, whose values are determined by
+// opts.Padding (zero for an empty a). Padding elements take part in
+// the maximum like those of a; argmax holds the position of the element
+// of a they stand for, or -1 for a zero padding element.
+func (a *Matrix) MaxPoolWith(kh, kw, stride int, opts *PoolOptions) (p *Matrix, argmax []int) {
+	if opts == nil {
+		opts = new(PoolOptions)
+	}
+	n, m := a.Len()
+	pn, pm := im2colLen(n, m, kh, kw, stride, opts.Pad)
+	p = NewMatrix(pn, pm)
+	argmax = make([]int, pn*pm)
+	for i := 0; i < pn; i++ {
+		for j := 0; j < pm; j++ {
+			i0, j0 := i*stride-opts.Pad, j*stride-opts.Pad
+			max, k := a.padded(i0, j0, opts.Padding)
+			for u := i0; u < i0+kh; u++ {
+				for v := j0; v < j0+kw; v++ {
+					if x, l := a.padded(u, v, opts.Padding); x > max {
+						max, k = x, l
+					}
+				}
+			}
+			p[i, j] = max
+			argmax[i*pm+j] = k
+		}
+	}
+	return
+}
+
+// AvgPool returns the average of each kh×kw window of a,
+// with windows placed as for MaxPool.
+func (a *Matrix) AvgPool(kh, kw, stride int) *Matrix {
+	return a.AvgPoolWith(kh, kw, stride, nil)
+}
+
+// AvgPoolWith is like AvgPool, but with windows placed as for
+// MaxPoolWith. Padding elements count towards the kh*kw elements
+// averaged.
+func (a *Matrix) AvgPoolWith(kh, kw, stride int, opts *PoolOptions) *Matrix {
+	if opts == nil {
+		opts = new(PoolOptions)
+	}
+	n, m := a.Len()
+	pn, pm := im2colLen(n, m, kh, kw, stride, opts.Pad)
+	p := NewMatrix(pn, pm)
+	for i := 0; i < pn; i++ {
+		for j := 0; j < pm; j++ {
+			i0, j0 := i*stride-opts.Pad, j*stride-opts.Pad
+			var t T
+			for u := i0; u < i0+kh; u++ {
+				for v := j0; v < j0+kw; v++ {
+					x, _ := a.padded(u, v, opts.Padding)
+					t += x
+				}
+			}
+			p[i, j] = t / T(kh*kw)
+		}
+	}
+	return p
+}
+
+// padded returns the element (i, j) of a extended by padding, and its
+// position i*m + j in a, or -1 if it is a zero padding element.
+func (a *Matrix) padded(i, j int, padding Padding) (T, int) {
+	n, m := a.Len()
+	if n == 0 || m == 0 {
+		return 0, -1
+	}
+	ii, ok := padding.index(i, n)
+	if !ok {
+		return 0, -1
+	}
+	jj, ok := padding.index(j, m)
+	if !ok {
+		return 0, -1
+	}
+	return a[ii, jj], ii*m + jj
+}
+
+// im2colLen returns the number of kh×kw windows with the given stride
+// that fit into an n×m matrix padded by pad elements on every side.
+func im2colLen(n, m, kh, kw, stride, pad int) (int, int) {
+	if pad < 0 {
+		panic("invalid padding")
+	}
+	return poolLen(n+2*pad, m+2*pad, kh, kw, stride)
+}
+
+// Im2Col returns the kh×kw windows of a, placed stride elements apart
+// in a padded by pad zeros on every side, as the columns of a matrix.
+// The result has kh*kw rows, and one column per window (in row-major
+// window order), holding the window elements in row-major order.
+// With it, the correlation of a with a kernel k is the single product
+// of k flattened into a 1×(kh*kw) matrix and the result.
+func (a *Matrix) Im2Col(kh, kw, stride, pad int) *Matrix {
+	n, m := a.Len()
+	on, om := im2colLen(n, m, kh, kw, stride, pad)
+	c := NewMatrix(kh*kw, on*om)
+	for i := 0; i < on; i++ {
+		for j := 0; j < om; j++ {
+			col := i*om + j
+			for u := 0; u < kh; u++ {
+				ii := i*stride + u - pad
+				for v := 0; v < kw; v++ {
+					jj := j*stride + v - pad
+					if 0 <= ii && ii < n && 0 <= jj && jj < m {
+						c[u*kw+v, col] = a[ii, jj]
+					}
+				}
+			}
+		}
+	}
+	return c
+}
+
+// Col2Im is the inverse (more precisely, the adjoint) of Im2Col: it
+// returns the n×m matrix obtained by adding each column of c, which was
+// produced by Im2Col with the same parameters, back into its window.
+// Elements covered by multiple windows receive the sum of their copies.
+func Col2Im(c *Matrix, n, m, kh, kw, stride, pad int) *Matrix {
+	on, om := im2colLen(n, m, kh, kw, stride, pad)
+	if cn, cm := c.Len(); cn != kh*kw || cm != on*om {
+		panic("incompatible matrix sizes")
+	}
+	a := NewMatrix(n, m)
+	for i := 0; i < on; i++ {
+		for j := 0; j < om; j++ {
+			col := i*om + j
+			for u := 0; u < kh; u++ {
+				ii := i*stride + u - pad
+				for v := 0; v < kw; v++ {
+					jj := j*stride + v - pad
+					if 0 <= ii && ii < n && 0 <= jj && jj < m {
+						a[ii, jj] += c[u*kw+v, col]
+					}
+				}
+			}
+		}
+	}
+	return a
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A COO is a sparse matrix in coordinate format: a list of (row,
+// column, value) triplets, in any order and possibly with duplicates,
+// which are summed. It is the format for assembling sparse matrices
+// element by element; convert to CSR for computations.
+type COO struct {
+	len  Shape
+	i, j []int
+	v    []T
+}
+
+// NewCOO returns an empty n×m matrix in COO format.
+func NewCOO(n, m int) *COO {
+	if n < 0 || m < 0 {
+		panic("invalid length")
+	}
+	return &COO{len: Shape{n, m}}
+}
+
+func (t *COO) Len() (int, int) { return t.len[0], t.len[1] }
+
+// Append adds v to element (i, j) of t. Zero values are not stored.
+func (t *COO) Append(i, j int, v T) {
+	if uint(i) >= uint(t.len[0]) || uint(j) >= uint(t.len[1]) {
+		panic("index out of bounds")
+	}
+	if v != 0 {
+		t.i = append(t.i, i)
+		t.j = append(t.j, j)
+		t.v = append(t.v, v)
+	}
+}
+
+// NNZ returns the number of stored triplets of t, counting duplicates.
+func (t *COO) NNZ() int { return len(t.v) }
+
+// NonZeros calls f for each stored triplet of t, in the order in which
+// they were appended, until f returns false.
+func (t *COO) NonZeros(f func(i, j int, v T) bool) {
+	for k, v := range t.v {
+		if !f(t.i[k], t.j[k], v) {
+			return
+		}
+	}
+}
+
+// MulVec returns the matrix-vector product t*x.
+func (t *COO) MulVec(x *Vector) *Vector {
+	n, m := t.Len()
+	if x.Len() != m {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(n)
+	for k, v := range t.v {
+		y[t.i[k]] += v * x[t.j[k]]
+	}
+	return y
+}
+
+// Dense returns t as a dense matrix.
+func (t *COO) Dense() *Matrix {
+	n, m := t.Len()
+	a := NewMatrix(n, m)
+	for k, v := range t.v {
+		a[t.i[k], t.j[k]] += v
+	}
+	return a
+}
+
+// CSR returns t in CSR format. Duplicate entries are summed.
+func (t *COO) CSR() *CSR {
+	n := t.len[0]
+	s := &CSR{len: t.len, ptr: make([]int, n+1)}
+
+	// bucket the entries by row
+	for _, i := range t.i {
+		s.ptr[i+1]++
+	}
+	for i := 0; i < n; i++ {
+		s.ptr[i+1] += s.ptr[i]
+	}
+	ind := make([]int, len(t.i))
+	val := make([]T, len(t.i))
+	next := append([]int(nil), s.ptr[:n]...)
+	for k, i := range t.i {
+		ind[next[i]] = t.j[k]
+		val[next[i]] = t.v[k]
+		next[i]++
+	}
+
+	s.ind, s.val = ind, val
+	s.canonicalize()
+	return s
+}
+
+// COO returns the nonzero elements of a in COO format, in row-major
+// order.
+func (a *Matrix) COO() *COO {
+	n, m := a.Len()
+	t := NewCOO(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			t.Append(i, j, a[i, j])
+		}
+	}
+	return t
+}
+
+// CSR returns a in CSR format, without its zero elements.
+func (a *Matrix) CSR() *CSR {
+	return a.COO().CSR()
+}
@@ -0,0 +1,146 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "sort"
+
+// A CSC is a sparse matrix in compressed sparse column format. The row
+// indices and values of the nonzero elements of column j are
+// ind[ptr[j]:ptr[j+1]] and val[ptr[j]:ptr[j+1]], with the row indices
+// in increasing order. The CSC form of a matrix is the CSR form of its
+// transpose, so column access and products with the transpose are
+// cheap.
+type CSC struct {
+	len Shape
+	ptr []int // len[1]+1 column pointers
+	ind []int
+	val []T
+}
+
+func (s *CSC) Len() (int, int) { return s.len[0], s.len[1] }
+
+func (s *CSC) [] (i, j int) T {
+	if boundsChecks && (uint(i) >= uint(s.len[0]) || uint(j) >= uint(s.len[1])) {
+		panic("index out of bounds")
+	}
+	ind := s.ind[s.ptr[j]:s.ptr[j+1]]
+	if k := sort.SearchInts(ind, i); k < len(ind) && ind[k] == i {
+		return s.val[s.ptr[j]+k]
+	}
+	return 0
+}
+
+// NNZ returns the number of stored elements of s.
+func (s *CSC) NNZ() int { return s.ptr[s.len[1]] }
+
+// NonZeros calls f for each stored element of s in column-major
+// order, until f returns false.
+func (s *CSC) NonZeros(f func(i, j int, v T) bool) {
+	for j := 0; j < s.len[1]; j++ {
+		for k := s.ptr[j]; k < s.ptr[j+1]; k++ {
+			if !f(s.ind[k], j, s.val[k]) {
+				return
+			}
+		}
+	}
+}
+
+// MulVec returns the matrix-vector product s*x.
+func (s *CSC) MulVec(x *Vector) *Vector {
+	n, m := s.Len()
+	if x.Len() != m {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(n)
+	for j := 0; j < m; j++ {
+		xj := x[j]
+		for k := s.ptr[j]; k < s.ptr[j+1]; k++ {
+			y[s.ind[k]] += s.val[k] * xj
+		}
+	}
+	return y
+}
+
+// MulVecTrans returns the matrix-vector product sᵀ*x.
+func (s *CSC) MulVecTrans(x *Vector) *Vector {
+	n, m := s.Len()
+	if x.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(m)
+	for j := 0; j < m; j++ {
+		var t T
+		for k := s.ptr[j]; k < s.ptr[j+1]; k++ {
+			t += s.val[k] * x[s.ind[k]]
+		}
+		y[j] = t
+	}
+	return y
+}
+
+// Dense returns s as a dense matrix.
+func (s *CSC) Dense() *Matrix {
+	n, m := s.Len()
+	a := NewMatrix(n, m)
+	for j := 0; j < m; j++ {
+		for k := s.ptr[j]; k < s.ptr[j+1]; k++ {
+			a[s.ind[k], j] = s.val[k]
+		}
+	}
+	return a
+}
+
+// CSC returns s in CSC format.
+func (s *CSR) CSC() *CSC {
+	ptr, ind, val := transposeCompressed(s.len[0], s.len[1], s.ptr, s.ind, s.val)
+	return &CSC{s.len, ptr, ind, val}
+}
+
+// CSR returns s in CSR format.
+func (s *CSC) CSR() *CSR {
+	ptr, ind, val := transposeCompressed(s.len[1], s.len[0], s.ptr, s.ind, s.val)
+	return &CSR{s.len, ptr, ind, val}
+}
+
+// Transpose returns the transpose of s.
+func (s *CSR) Transpose() *CSR {
+	ptr, ind, val := transposeCompressed(s.len[0], s.len[1], s.ptr, s.ind, s.val)
+	return &CSR{s.len.transpose(), ptr, ind, val}
+}
+
+// Transpose returns the transpose of s.
+func (s *CSC) Transpose() *CSC {
+	ptr, ind, val := transposeCompressed(s.len[1], s.len[0], s.ptr, s.ind, s.val)
+	return &CSC{s.len.transpose(), ptr, ind, val}
+}
+
+// transposeCompressed transposes the compressed sparse structure with
+// n major (rows for CSR) and m minor indices into one with m major
+// indices, with a counting sort in O(n + m + nnz) time. The minor
+// indices of the result are in increasing order.
+func transposeCompressed(n, m int, ptr, ind []int, val []T) (tptr, tind []int, tval []T) {
+	nnz := ptr[n]
+	tptr = make([]int, m+1)
+	for _, j := range ind[:nnz] {
+		tptr[j+1]++
+	}
+	for j := 0; j < m; j++ {
+		tptr[j+1] += tptr[j]
+	}
+	tind = make([]int, nnz)
+	tval = make([]T, nnz)
+	next := append([]int(nil), tptr[:m]...)
+	for i := 0; i < n; i++ {
+		for k := ptr[i]; k < ptr[i+1]; k++ {
+			j := ind[k]
+			tind[next[j]] = i
+			tval[next[j]] = val[k]
+			next[j]++
+		}
+	}
+	return
+}
@@ -0,0 +1,162 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions controls the behavior of a CSVReader.
+// A nil *CSVOptions is equivalent to the zero value.
+type CSVOptions struct {
+	// Comma is the field delimiter; 0 means ','.
+	Comma rune
+
+	// If Header is set, the first record holds the column names
+	// (see CSVReader.Header) rather than data.
+	Header bool
+
+	// BatchRows is the maximum number of rows per batch;
+	// 0 means 1024.
+	BatchRows int
+}
+
+// A CSVReader reads numeric CSV data in batches of rows, so that
+// datasets too large to be held in memory at once can be processed
+// one batch at a time. All records must have the same number of
+// fields. Empty fields are read as NaN, marking missing values.
+type CSVReader struct {
+	r      *csv.Reader
+	batch  int
+	cols   int // -1 until the first record has been read
+	header []string
+	row    int // number of data rows read
+	err    error
+}
+
+// NewCSVReader returns a CSVReader reading from r.
+func NewCSVReader(r io.Reader, opts *CSVOptions) *CSVReader {
+	if opts == nil {
+		opts = new(CSVOptions)
+	}
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1 // checked by parse
+	batch := opts.BatchRows
+	if batch <= 0 {
+		batch = 1024
+	}
+	c := &CSVReader{r: cr, batch: batch, cols: -1}
+	if opts.Header {
+		c.header, c.err = cr.Read()
+		if c.err == nil {
+			c.cols = len(c.header)
+		}
+	}
+	return c
+}
+
+// Header returns the column names read from the header record, or nil
+// if there is none.
+func (c *CSVReader) Header() []string { return c.header }
+
+// Next returns the next batch of at most BatchRows rows. The last
+// batch may be shorter; after it, Next returns io.EOF. An error in a
+// record, reported with its position, ends the stream.
+func (c *CSVReader) Next() (*Matrix, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	var rows [][]T
+	for len(rows) < c.batch {
+		rec, err := c.r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.err = err
+			return nil, err
+		}
+		row, err := c.parse(rec)
+		if err != nil {
+			c.err = err
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		c.err = io.EOF
+		return nil, io.EOF
+	}
+	a := NewMatrix(len(rows), c.cols)
+	for i, row := range rows {
+		for j, x := range row {
+			a[i, j] = x
+		}
+	}
+	return a, nil
+}
+
+// parse converts the fields of a data record.
+func (c *CSVReader) parse(rec []string) ([]T, error) {
+	c.row++
+	if c.cols < 0 {
+		c.cols = len(rec)
+	}
+	if len(rec) != c.cols {
+		return nil, fmt.Errorf("csv: data row %d: %d fields, want %d", c.row, len(rec), c.cols)
+	}
+	row := make([]T, len(rec))
+	for j, f := range rec {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			row[j] = T(math.NaN())
+			continue
+		}
+		x, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, fmt.Errorf("csv: data row %d, column %d: %v", c.row, j+1, err)
+		}
+		row[j] = T(x)
+	}
+	return row, nil
+}
+
+// ReadCSV reads all of the numeric CSV data from r into one matrix.
+func ReadCSV(r io.Reader, opts *CSVOptions) (*Matrix, []string, error) {
+	c := NewCSVReader(r, opts)
+	var batches []*Matrix
+	for {
+		b, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		batches = append(batches, b)
+	}
+	if len(batches) == 0 {
+		m := c.cols
+		if m < 0 {
+			m = 0
+		}
+		return NewMatrix(0, m), c.Header(), nil
+	}
+	if len(batches) == 1 {
+		return batches[0], c.Header(), nil
+	}
+	return VStack(batches...), c.Header(), nil
+}
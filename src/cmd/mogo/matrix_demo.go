@@ -0,0 +1,47 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "fmt"
+
+func main() {
+	a := NewMatrix(4, 5)
+	a.Set(
+		4, 2, 7, 9, 1,
+		5, 0, 1, 8, 3,
+		5, 6, 3, 2, 1,
+		7, 9, 0, 1, 2,
+	)
+
+	b := NewMatrix(5, 3)
+	b.Set(
+		3, 4, 5,
+		0, 3, 1,
+		3, 2, 1,
+		8, 2, 6,
+		2, 7, 1,
+	)
+
+	(a * b).Print()
+
+	c := a.Mul(b)
+	c.Print()
+
+	c.Transpose().Print()
+
+	n, m := c.Len()
+	for i := 0; i < n; i++ {
+		fmt.Println(c.Row(i).GoSlice())
+	}
+
+	for j := 0; j < m; j++ {
+		fmt.Println(c.Col(j).GoSlice())
+	}
+	fmt.Println()
+
+	poissonDemo()
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// Diag returns the main diagonal of m as a view sharing m's storage.
+func (m *Matrix) Diag() *Vector { return m.DiagOffset(0) }
+
+// DiagOffset returns the k-th diagonal of m as a view sharing m's
+// storage: the main diagonal for k = 0, the k-th superdiagonal
+// (elements m[i, i+k]) for k > 0, and the -k-th subdiagonal for k < 0.
+func (m *Matrix) DiagOffset(k int) *Vector {
+	n, p := m.Len()
+	i, j := 0, k
+	if k < 0 {
+		i, j = -k, 0
+	}
+	l := n - i
+	if p-j < l {
+		l = p - j
+	}
+	if l < 0 {
+		panic("index out of bounds")
+	}
+	return &Vector{m.array, m.off + i*m.stride[0] + j*m.stride[1], l, m.stride[0] + m.stride[1]}
+}
+
+// Trace returns the sum of the diagonal elements of the square matrix a.
+func (a *Matrix) Trace() T {
+	MustBeSquare("Trace", a)
+	var t T
+	d := a.Diag()
+	for i := 0; i < d.Len(); i++ {
+		t += d[i]
+	}
+	return t
+}
+
+// A Diagonal is a square diagonal matrix, stored as the vector of its
+// diagonal elements. Products with a Diagonal scale rows or columns,
+// in O(n*m) time for an n×m operand.
+type Diagonal struct {
+	d *Vector
+}
+
+// NewDiagonal returns the diagonal matrix with diagonal d, sharing d's
+// storage.
+func NewDiagonal(d *Vector) *Diagonal { return &Diagonal{d} }
+
+func (d *Diagonal) Len() (int, int) { return d.d.Len(), d.d.Len() }
+func (d *Diagonal) [] (i, j int) T {
+	if uint(i) >= uint(d.d.Len()) || uint(j) >= uint(d.d.Len()) {
+		panic("index out of bounds")
+	}
+	if i != j {
+		return 0
+	}
+	return d.d[i]
+}
+
+// Diag returns the diagonal of d, sharing d's storage.
+func (d *Diagonal) Diag() *Vector  { return d.d }
+func (d *Diagonal) Dense() *Matrix { return denseOf(d) }
+
+// MulVec returns the product d*x.
+func (d *Diagonal) MulVec(x *Vector) *Vector {
+	if x.Len() != d.d.Len() {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(x.Len())
+	for i := 0; i < x.Len(); i++ {
+		y[i] = d.d[i] * x[i]
+	}
+	return y
+}
+
+// Mul returns the product d*b, which scales row i of b by d[i, i].
+func (d *Diagonal) Mul(b *Matrix) *Matrix {
+	n, m := b.Len()
+	if n != d.d.Len() {
+		panic("incompatible matrix sizes")
+	}
+	c := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		s := d.d[i]
+		for j := 0; j < m; j++ {
+			c[i, j] = s * b[i, j]
+		}
+	}
+	return c
+}
+
+// MulDiag returns the product a*d, which scales column j of a by
+// d[j, j].
+func (a *Matrix) MulDiag(d *Diagonal) *Matrix {
+	n, m := a.Len()
+	if m != d.d.Len() {
+		panic("incompatible matrix sizes")
+	}
+	c := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[i, j] = a[i, j] * d.d[j]
+		}
+	}
+	return c
+}
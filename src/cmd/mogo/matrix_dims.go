@@ -0,0 +1,54 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A DimError describes an operation applied to matrices of unsuitable
+// sizes. The Must functions panic with a *DimError, which code
+// recovering from the panic can inspect.
+type DimError struct {
+	Op     string  // operation, such as "MulTo" or "LU"
+	Shapes []Shape // operand shapes
+	Msg    string  // the problem, such as "matrix not square"
+}
+
+func (e *DimError) Error() string {
+	s := make([]string, len(e.Shapes))
+	for i, sh := range e.Shapes {
+		s[i] = fmt.Sprintf("%d×%d", sh[0], sh[1])
+	}
+	return e.Op + ": " + e.Msg + ": " + strings.Join(s, " and ")
+}
+
+// MustBeSquare panics with a *DimError for operation op unless a is
+// square.
+func MustBeSquare(op string, a *Matrix) {
+	if a.len[0] != a.len[1] {
+		panic(&DimError{op, []Shape{a.len}, "matrix not square"})
+	}
+}
+
+// MustMatchMul panics with a *DimError for operation op unless the
+// matrix product a*b is defined, that is, unless a has as many columns
+// as b has rows.
+func MustMatchMul(op string, a, b *Matrix) {
+	if a.len[1] != b.len[0] {
+		panic(&DimError{op, []Shape{a.len, b.len}, "incompatible matrix sizes"})
+	}
+}
+
+// MustMatchShape panics with a *DimError for operation op unless a and
+// b have the same shape, as required by element-wise operations.
+func MustMatchShape(op string, a, b *Matrix) {
+	if a.len != b.len {
+		panic(&DimError{op, []Shape{a.len, b.len}, "incompatible matrix sizes"})
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "context"
+
+// normalizedRows returns a copy of a with each row scaled to unit
+// Euclidean norm; zero rows stay zero.
+func normalizedRows(a *Matrix) *Matrix {
+	c := a.Copy()
+	n, m := c.Len()
+	for i := 0; i < n; i++ {
+		row := c.Row(i)
+		if norm := sqrt(row * row); norm > 0 {
+			for j := 0; j < m; j++ {
+				row[j] /= norm
+			}
+		}
+	}
+	return c
+}
+
+// CosineSim returns the n×n matrix of cosine similarities
+// x·y / (|x| |y|) between all pairs of rows x, y of the n×m matrix a,
+// computed as a single product of the row-normalized matrix with its
+// transpose. The similarity involving a zero row is 0.
+func (a *Matrix) CosineSim() *Matrix {
+	c := normalizedRows(a)
+	return c * c.Transpose()
+}
+
+// CosineSimUpper is like CosineSim but returns only the upper triangle
+// (including the diagonal) of the symmetric result, packed row by row
+// into a slice of n(n+1)/2 elements; use PackedIndex to locate the
+// similarity of rows i and j.
+func (a *Matrix) CosineSimUpper() []T {
+	c := normalizedRows(a)
+	n, _ := c.Len()
+	s := make([]T, 0, n*(n+1)/2)
+	for i := 0; i < n; i++ {
+		ci := c.Row(i)
+		for j := i; j < n; j++ {
+			s = append(s, ci*c.Row(j))
+		}
+	}
+	return s
+}
+
+// PackedIndex returns the index of element [i, j] of a symmetric n×n
+// matrix whose upper triangle is packed row by row into a slice.
+func PackedIndex(n, i, j int) int {
+	if i > j {
+		i, j = j, i
+	}
+	if boundsChecks && (i < 0 || j >= n) {
+		panic("index out of bounds")
+	}
+	return i*n - i*(i-1)/2 + j - i
+}
+
+// A Metric selects the distance function used by PairwiseDist.
+type Metric int
+
+const (
+	MetricEuclidean Metric = iota // |x - y|₂
+	MetricManhattan               // |x - y|₁
+	MetricCosine                  // 1 - cosine similarity of x and y
+)
+
+// distBlock is the number of rows of the second operand processed
+// together by pairwiseDist, so that they stay in cache while the rows
+// of the first operand of a chunk are compared against them.
+const distBlock = 64
+
+// PairwiseDist returns the n×n matrix of distances under metric
+// between all pairs of rows of the n×m matrix a. The rows are
+// processed blockwise and in parallel. Euclidean distances are
+// computed via |x|² + |y|² - 2x·y, which is fast but may lose
+// accuracy for nearby points far from the origin; the diagonal is
+// exactly zero.
+func (a *Matrix) PairwiseDist(metric Metric) *Matrix {
+	d := pairwiseDist(a, a, metric)
+	n, _ := a.Len()
+	for i := 0; i < n; i++ {
+		d[i, i] = 0
+	}
+	return d
+}
+
+// pairwiseDist returns the n×p matrix of distances under metric
+// between the rows of the n×m matrix x and those of the p×m matrix y.
+func pairwiseDist(x, y *Matrix, metric Metric) *Matrix {
+	n, m := x.Len()
+	p, ym := y.Len()
+	if m != ym {
+		panic("incompatible matrix sizes")
+	}
+	var xsq, ysq []T
+	switch metric {
+	case MetricEuclidean:
+		xsq, ysq = rowSquares(x), rowSquares(y)
+	case MetricManhattan:
+	case MetricCosine:
+		x, y = normalizedRows(x), normalizedRows(y)
+	default:
+		panic("invalid metric")
+	}
+
+	d := NewMatrix(n, p)
+	parallelFor(context.Background(), n, rowGrain(n), func(lo, hi int) {
+		for j0 := 0; j0 < p; j0 += distBlock {
+			j1 := j0 + distBlock
+			if j1 > p {
+				j1 = p
+			}
+			for i := lo; i < hi; i++ {
+				xi := x.Row(i)
+				for j := j0; j < j1; j++ {
+					yj := y.Row(j)
+					var t T
+					switch metric {
+					case MetricEuclidean:
+						if t = xsq[i] + ysq[j] - 2*(xi*yj); t < 0 {
+							t = 0 // rounding
+						}
+						t = sqrt(t)
+					case MetricManhattan:
+						for k := 0; k < m; k++ {
+							t += abs(xi[k] - yj[k])
+						}
+					case MetricCosine:
+						t = 1 - xi*yj
+					}
+					d[i, j] = t
+				}
+			}
+		}
+	})
+	return d
+}
+
+// rowSquares returns the squared Euclidean norms of the rows of a.
+func rowSquares(a *Matrix) []T {
+	n, _ := a.Len()
+	s := make([]T, n)
+	for i := range s {
+		row := a.Row(i)
+		s[i] = row * row
+	}
+	return s
+}
+
+// A NeighborIndex answers k-nearest-neighbor queries over the rows of
+// a data matrix. BruteForceIndex is the exact, index-free
+// implementation; tree or graph based indexes can implement the same
+// interface.
+type NeighborIndex interface {
+	// KNN returns, for each row of query, the row indices of the k
+	// nearest data rows in order of increasing distance, and the
+	// corresponding distances as a matrix with k columns.
+	KNN(query *Matrix, k int) (idx [][]int, dist *Matrix)
+}
+
+// A BruteForceIndex finds nearest neighbors by comparing each query
+// against all data rows.
+type BruteForceIndex struct {
+	data   *Matrix
+	metric Metric
+}
+
+// NewBruteForceIndex returns a BruteForceIndex for the rows of data
+// under metric. The index refers to data; it must not be modified
+// while the index is in use.
+func NewBruteForceIndex(data *Matrix, metric Metric) *BruteForceIndex {
+	return &BruteForceIndex{data, metric}
+}
+
+// knnBlock is the number of queries whose distances to all data rows
+// are computed at once by BruteForceIndex.KNN, bounding the memory
+// used for the distance block.
+const knnBlock = 256
+
+func (x *BruteForceIndex) KNN(query *Matrix, k int) (idx [][]int, dist *Matrix) {
+	q, _ := query.Len()
+	p, _ := x.data.Len()
+	if k < 0 || k > p {
+		panic("invalid number of neighbors")
+	}
+	idx = make([][]int, q)
+	dist = NewMatrix(q, k)
+	for i0 := 0; i0 < q; i0 += knnBlock {
+		i1 := i0 + knnBlock
+		if i1 > q {
+			i1 = q
+		}
+		d := pairwiseDist(rowRange(query, i0, i1), x.data, x.metric)
+		for i := i0; i < i1; i++ {
+			idx[i] = nearest(d.Row(i-i0), k)
+			for l, j := range idx[i] {
+				dist[i, l] = d[i-i0, j]
+			}
+		}
+	}
+	return idx, dist
+}
+
+// nearest returns the indices of the k smallest elements of d in
+// increasing order; ties are broken by index.
+func nearest(d *Vector, k int) []int {
+	s := make([]int, 0, k)
+	for j := 0; j < d.Len(); j++ {
+		if len(s) == k && (k == 0 || d[j] >= d[s[k-1]]) {
+			continue
+		}
+		if len(s) < k {
+			s = append(s, j)
+		}
+		// insert j in sorted position, dropping the largest if full
+		l := len(s) - 1
+		for ; l > 0 && d[s[l-1]] > d[j]; l-- {
+			s[l] = s[l-1]
+		}
+		s[l] = j
+	}
+	return s
+}
+
+// KNN returns, for each row of query, the indices of the k nearest
+// rows of a in Euclidean distance, and the distances. It uses a
+// BruteForceIndex; see NeighborIndex.
+func (a *Matrix) KNN(query *Matrix, k int) (idx [][]int, dist *Matrix) {
+	return NewBruteForceIndex(a, MetricEuclidean).KNN(query, k)
+}
@@ -0,0 +1,243 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// Destination variants of the matrix operations. Each stores its
+// result in the existing matrix dst, which must have the size of the
+// result, and returns dst; reusing dst avoids an allocation per
+// operation in loops. dst must not share storage with an operand,
+// except that element-wise operations may use an operand (the very
+// same view) as dst.
+
+// extent returns the range [lo, hi] of indices of a.array that
+// elements of a may occupy. It is empty (lo > hi) if a is empty.
+func (a *Matrix) extent() (lo, hi int) {
+	n, m := a.Len()
+	if n == 0 || m == 0 {
+		return 0, -1
+	}
+	lo, hi = a.off, a.off
+	for d, k := range [2]int{n - 1, m - 1} {
+		if s := k * a.stride[d]; s < 0 {
+			lo += s
+		} else {
+			hi += s
+		}
+	}
+	return
+}
+
+// overlap reports whether a and b may share elements.
+func overlap(a, b *Matrix) bool {
+	alo, ahi := a.extent()
+	blo, bhi := b.extent()
+	if alo > ahi || blo > bhi {
+		return false
+	}
+	// same underlying array if the slices end at the same address
+	ae, be := a.array[:cap(a.array)], b.array[:cap(b.array)]
+	if &ae[len(ae)-1] != &be[len(be)-1] {
+		return false
+	}
+	off := cap(b.array) - cap(a.array) // position of a.array[0] in b's array
+	return alo+off <= bhi && blo <= ahi+off
+}
+
+// sameView reports whether a and b are the same view of the same
+// storage.
+func sameView(a, b *Matrix) bool {
+	return overlap(a, b) && cap(a.array) == cap(b.array) && a.off == b.off && a.len == b.len && a.stride == b.stride
+}
+
+// MulTo stores the matrix product a*b in dst and returns dst.
+func MulTo(dst, a, b *Matrix) *Matrix {
+	MustMatchMul("MulTo", a, b)
+	n, m := a.Len()
+	_, p := b.Len()
+	if dn, dm := dst.Len(); dn != n || dm != p {
+		panic("incompatible matrix sizes")
+	}
+	if overlap(dst, a) || overlap(dst, b) {
+		panic("overlapping matrices")
+	}
+	if cutoff, ok := useStrassen(n, m, p); ok {
+		strassenTo(dst, a, b, cutoff)
+		return dst
+	}
+	mulKernel(dst, a, b)
+	return dst
+}
+
+// Block sizes of mulKernel: a packed mulBlockK×mulBlockJ block of b
+// (128 KiB for float64) stays in the L2 cache while it is multiplied
+// with all rows of a, and the row segment of a it meets stays in L1.
+const (
+	mulBlockJ = 64
+	mulBlockK = 256
+
+	// below this many multiply-adds n*m*p, packing does not pay off
+	mulBlockThreshold = 1 << 15
+)
+
+// mulKernel stores a*b in dst, whose sizes have been checked. Larger
+// products are computed block by block: each block of b is packed in
+// transposed order, so that every element of dst is the dot product of
+// two contiguous slices. Each element is still accumulated in order of
+// increasing k, so the result does not depend on the blocking.
+func mulKernel(dst, a, b *Matrix) {
+	n, m := a.Len()
+	_, p := b.Len()
+	if n*m*p < mulBlockThreshold {
+		for i := 0; i < n; i++ {
+			for j := 0; j < p; j++ {
+				var t T
+				for k := 0; k < m; k++ {
+					t += a[i, k] * b[k, j]
+				}
+				dst[i, j] = t
+			}
+		}
+		return
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < p; j++ {
+			dst[i, j] = 0
+		}
+	}
+	bt := make([]T, mulBlockJ*mulBlockK) // bᵀ block, row by row
+	apack := make([]T, mulBlockK)        // row segment of a, if strided
+	for j0 := 0; j0 < p; j0 += mulBlockJ {
+		j1 := j0 + mulBlockJ
+		if j1 > p {
+			j1 = p
+		}
+		for k0 := 0; k0 < m; k0 += mulBlockK {
+			k1 := k0 + mulBlockK
+			if k1 > m {
+				k1 = m
+			}
+			kb := k1 - k0
+			for k := k0; k < k1; k++ {
+				for j := j0; j < j1; j++ {
+					bt[(j-j0)*kb+k-k0] = b[k, j]
+				}
+			}
+			for i := 0; i < n; i++ {
+				var as []T
+				if a.stride[1] == 1 {
+					as = a.array[a.off+i*a.stride[0]+k0:][:kb]
+				} else {
+					as = apack[:kb]
+					for k := range as {
+						as[k] = a[i, k0+k]
+					}
+				}
+				for j := j0; j < j1; j++ {
+					bs := bt[(j-j0)*kb:][:kb]
+					t := dst[i, j]
+					for k, x := range as {
+						t += x * bs[k]
+					}
+					dst[i, j] = t
+				}
+			}
+		}
+	}
+}
+
+// AddTo stores the sum a+b in dst and returns dst.
+func AddTo(dst, a, b *Matrix) *Matrix {
+	return elementwiseTo(dst, a, b, func(x, y T) T { return x + y })
+}
+
+// SubTo stores the difference a-b in dst and returns dst.
+func SubTo(dst, a, b *Matrix) *Matrix {
+	return elementwiseTo(dst, a, b, func(x, y T) T { return x - y })
+}
+
+// MulElemTo stores the element-wise product of a and b in dst and
+// returns dst.
+func MulElemTo(dst, a, b *Matrix) *Matrix {
+	return elementwiseTo(dst, a, b, func(x, y T) T { return x * y })
+}
+
+// ScaleTo stores the product alpha*a in dst and returns dst.
+func ScaleTo(dst *Matrix, alpha T, a *Matrix) *Matrix {
+	return elementwiseTo(dst, a, a, func(x, _ T) T { return alpha * x })
+}
+
+func elementwiseTo(dst, a, b *Matrix, f func(x, y T) T) *Matrix {
+	MustMatchShape("element-wise operation", a, b)
+	if !dst.SameShape(a) {
+		panic("incompatible matrix sizes")
+	}
+	for _, x := range [...]*Matrix{a, b} {
+		if overlap(dst, x) && !sameView(dst, x) {
+			panic("overlapping matrices")
+		}
+	}
+	n, m := a.Len()
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			dst[i, j] = f(a[i, j], b[i, j])
+		}
+	}
+	return dst
+}
+
+// CopyTo copies the elements of a into dst and returns dst.
+func CopyTo(dst, a *Matrix) *Matrix {
+	if !dst.SameShape(a) {
+		panic("incompatible matrix sizes")
+	}
+	if sameView(dst, a) {
+		return dst
+	}
+	if overlap(dst, a) {
+		panic("overlapping matrices")
+	}
+	n, m := a.Len()
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			dst[i, j] = a[i, j]
+		}
+	}
+	return dst
+}
+
+// TransposeCopyTo stores the transpose of a in dst and returns dst.
+// Like TransposeCopy, it copies block by block.
+func TransposeCopyTo(dst, a *Matrix) *Matrix {
+	n, m := a.Len()
+	if dst.len != a.len.transpose() {
+		panic("incompatible matrix sizes")
+	}
+	if overlap(dst, a) {
+		panic("overlapping matrices")
+	}
+	s0, s1 := a.stride[0], a.stride[1]
+	d0, d1 := dst.stride[0], dst.stride[1]
+	for i0 := 0; i0 < n; i0 += transposeBlock {
+		i1 := i0 + transposeBlock
+		if i1 > n {
+			i1 = n
+		}
+		for j0 := 0; j0 < m; j0 += transposeBlock {
+			j1 := j0 + transposeBlock
+			if j1 > m {
+				j1 = m
+			}
+			for i := i0; i < i1; i++ {
+				for j := j0; j < j1; j++ {
+					dst.array[dst.off+j*d0+i*d1] = a.array[a.off+i*s0+j*s1]
+				}
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,568 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "math"
+
+// Balance returns a balanced matrix b = D⁻¹ * a * D similar to the
+// square matrix a, and the diagonal of D. The scaling factors are
+// powers of 2, so balancing introduces no rounding errors; it makes
+// the norms of corresponding rows and columns comparable, which
+// improves the accuracy of computed eigenvalues of badly scaled
+// matrices. The eigenvalues of b are those of a, and an eigenvector v
+// of b corresponds to the eigenvector D*v of a.
+func (a *Matrix) Balance() (b *Matrix, d *Vector) {
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	const radix = 2
+	b = a.Copy()
+	d = NewVector(n)
+	for i := 0; i < n; i++ {
+		d[i] = 1
+	}
+	for done := false; !done; {
+		done = true
+		for i := 0; i < n; i++ {
+			// off-diagonal norms of column and row i
+			var c, r T
+			for j := 0; j < n; j++ {
+				if j != i {
+					c += abs(b[j, i])
+					r += abs(b[i, j])
+				}
+			}
+			if c == 0 || r == 0 {
+				continue
+			}
+			// find the power of radix f that best equalizes c*f and r/f
+			s := c + r
+			f := T(1)
+			for g := r / radix; c < g; c *= radix * radix {
+				f *= radix
+			}
+			for g := r * radix; c > g; c /= radix * radix {
+				f /= radix
+			}
+			if (c+r)/f < 0.95*s {
+				done = false
+				d[i] *= f
+				for j := 0; j < n; j++ {
+					b[i, j] /= f
+					b[j, i] *= f
+				}
+			}
+		}
+	}
+	return
+}
+
+// Eigenvalues returns the (complex) eigenvalues of the square matrix a,
+// with complex conjugate pairs next to each other. The eigenvalues are
+// computed from the real Schur form of the balanced matrix.
+func (a *Matrix) Eigenvalues() ([]complex128, error) {
+	b, _ := a.Balance()
+	t, _, err := schur(b, false)
+	if err != nil {
+		return nil, err
+	}
+	return schurEigenvalues(t), nil
+}
+
+// schurEigenvalues returns the eigenvalues of the quasi-upper
+// triangular matrix t.
+func schurEigenvalues(t *Matrix) []complex128 {
+	n, _ := t.Len()
+	ev := make([]complex128, 0, n)
+	for i := 0; i < n; {
+		if i == n-1 || t[i+1, i] == 0 {
+			ev = append(ev, complex(float64(t[i, i]), 0))
+			i++
+			continue
+		}
+		// 2×2 block with a complex conjugate pair of eigenvalues
+		a, b, c, d := t[i, i], t[i, i+1], t[i+1, i], t[i+1, i+1]
+		p := (a - d) / 2
+		re := float64(d + p)
+		im := math.Sqrt(math.Abs(float64(p*p + b*c)))
+		ev = append(ev, complex(re, im), complex(re, -im))
+		i += 2
+	}
+	return ev
+}
+
+// schur computes the real Schur decomposition a = z*t*zᵀ of the square
+// matrix a, where z is orthogonal and t is quasi-upper triangular: upper
+// triangular except for 2×2 diagonal blocks corresponding to complex
+// conjugate pairs of eigenvalues. z is only computed if wantZ is set.
+// The algorithm is the Hessenberg reduction followed by the Francis
+// double-shift QR iteration (see Golub and Van Loan, "Matrix
+// Computations", Section 7.5).
+func schur(a *Matrix, wantZ bool) (t, z *Matrix, err error) {
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	h := a.Copy()
+	if wantZ {
+		z = NewMatrix(n, n)
+		for i := 0; i < n; i++ {
+			z[i, i] = 1
+		}
+	}
+
+	// reduce to upper Hessenberg form
+	v := make([]T, n)
+	for k := 0; k < n-2; k++ {
+		x := v[:n-k-1]
+		for i := range x {
+			x[i] = h[k+1+i, k]
+		}
+		tau, beta := householder(x)
+		if tau == 0 {
+			continue
+		}
+		reflectRows(h, x, tau, k+1, k, n)
+		reflectCols(h, x, tau, k+1, 0, n)
+		if z != nil {
+			reflectCols(z, x, tau, k+1, 0, n)
+		}
+		h[k+1, k] = beta
+		for i := k + 2; i < n; i++ {
+			h[i, k] = 0
+		}
+	}
+
+	var anorm T
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			anorm += abs(h[i, j])
+		}
+	}
+
+	// QR iteration on the active window h[l:nn+1, l:nn+1]
+	its, total := 0, 0
+	for nn := n - 1; nn >= 0; {
+		// find the start l of the unreduced trailing Hessenberg block
+		l := nn
+		for ; l > 0; l-- {
+			s := abs(h[l-1, l-1]) + abs(h[l, l])
+			if s == 0 {
+				s = anorm
+			}
+			if abs(h[l, l-1]) <= epsilon*s {
+				h[l, l-1] = 0
+				break
+			}
+		}
+		switch l {
+		case nn:
+			// 1×1 block converged
+			nn--
+			its = 0
+			continue
+		case nn - 1:
+			// 2×2 block converged
+			standardize(h, z, nn-1)
+			nn -= 2
+			its = 0
+			continue
+		}
+		if total >= 30*n {
+			return nil, nil, ErrNoConvergence
+		}
+		its++
+		total++
+		francisStep(h, z, l, nn, its)
+	}
+	return h, z, nil
+}
+
+// francisStep performs a Francis double-shift QR step on the unreduced
+// Hessenberg block h[l:nn+1, l:nn+1] (with nn-l >= 2), updating the
+// rest of h and z (if not nil) accordingly. Every 10 iterations (its),
+// an exceptional shift is used to break potential cycles.
+func francisStep(h, z *Matrix, l, nn, its int) {
+	n, _ := h.Len()
+
+	// the shifts are the eigenvalues of a 2×2 matrix with trace s and determinant t
+	var s, t T
+	if its%10 == 0 {
+		w := abs(h[nn, nn-1]) + abs(h[nn-1, nn-2])
+		h11 := 0.75*w + h[nn, nn]
+		s = 2 * h11
+		t = h11*h11 + 0.4375*w*w
+	} else {
+		s = h[nn-1, nn-1] + h[nn, nn]
+		t = h[nn-1, nn-1]*h[nn, nn] - h[nn-1, nn]*h[nn, nn-1]
+	}
+
+	// first column of (H - s1*I)*(H - s2*I)
+	x := h[l, l]*h[l, l] + h[l, l+1]*h[l+1, l] - s*h[l, l] + t
+	y := h[l+1, l] * (h[l, l] + h[l+1, l+1] - s)
+	w := h[l+1, l] * h[l+2, l+1]
+
+	// chase the bulge
+	v := make([]T, 3)
+	for k := l; k <= nn-2; k++ {
+		v[0], v[1], v[2] = x, y, w
+		tau, beta := householder(v)
+		if tau != 0 {
+			c0 := k - 1
+			if c0 < l {
+				c0 = l
+			}
+			reflectRows(h, v, tau, k, c0, n)
+			r1 := k + 4
+			if r1 > nn+1 {
+				r1 = nn + 1
+			}
+			reflectCols(h, v, tau, k, 0, r1)
+			if z != nil {
+				reflectCols(z, v, tau, k, 0, n)
+			}
+			if k > l {
+				h[k, k-1] = beta
+				h[k+1, k-1] = 0
+				h[k+2, k-1] = 0
+			}
+		}
+		x, y = h[k+1, k], h[k+2, k]
+		if k < nn-2 {
+			w = h[k+3, k]
+		}
+	}
+	v = v[:2]
+	v[0], v[1] = x, y
+	if tau, beta := householder(v); tau != 0 {
+		reflectRows(h, v, tau, nn-1, nn-2, n)
+		reflectCols(h, v, tau, nn-1, 0, nn+1)
+		if z != nil {
+			reflectCols(z, v, tau, nn-1, 0, n)
+		}
+		h[nn-1, nn-2] = beta
+		h[nn, nn-2] = 0
+	}
+}
+
+// standardize transforms the converged 2×2 diagonal block of h at
+// (k, k) into upper triangular form if its eigenvalues are real,
+// updating the rest of h and z (if not nil) accordingly.
+func standardize(h, z *Matrix, k int) {
+	n, _ := h.Len()
+	a, b, c, d := h[k, k], h[k, k+1], h[k+1, k], h[k+1, k+1]
+	if c == 0 {
+		return
+	}
+	p := (a - d) / 2
+	disc := p*p + b*c
+	if disc < 0 {
+		return // complex conjugate pair
+	}
+	// rotate by G = [cs -sn; sn cs] whose first column is an eigenvector
+	lambda := d + p + T(math.Copysign(math.Sqrt(float64(disc)), float64(p)))
+	vx, vy := lambda-d, c
+	r := T(math.Hypot(float64(vx), float64(vy)))
+	cs, sn := vx/r, vy/r
+	for j := k; j < n; j++ {
+		x, y := h[k, j], h[k+1, j]
+		h[k, j] = cs*x + sn*y
+		h[k+1, j] = -sn*x + cs*y
+	}
+	rotateCols(h, cs, sn, k, k+2)
+	if z != nil {
+		rotateCols(z, cs, sn, k, n)
+	}
+	h[k+1, k] = 0
+}
+
+// rotateCols applies the rotation [cs -sn; sn cs] from the right to
+// columns k and k+1 of rows [0, r1) of a.
+func rotateCols(a *Matrix, cs, sn T, k, r1 int) {
+	for i := 0; i < r1; i++ {
+		x, y := a[i, k], a[i, k+1]
+		a[i, k] = cs*x + sn*y
+		a[i, k+1] = -sn*x + cs*y
+	}
+}
+
+// householder overwrites x with the vector v (v[0] = 1) of the
+// Householder reflection H = I - tau*v*vᵀ such that H*x = beta*e₁,
+// and returns tau and beta. If x is already a multiple of e₁, tau is 0.
+func householder(x []T) (tau, beta T) {
+	alpha := x[0]
+	var xnorm T
+	for _, xi := range x[1:] {
+		xnorm = T(math.Hypot(float64(xnorm), float64(xi)))
+	}
+	x[0] = 1
+	if xnorm == 0 {
+		return 0, alpha
+	}
+	beta = -T(math.Copysign(math.Hypot(float64(alpha), float64(xnorm)), float64(alpha)))
+	tau = (beta - alpha) / beta
+	s := 1 / (alpha - beta)
+	for i := 1; i < len(x); i++ {
+		x[i] *= s
+	}
+	return
+}
+
+// reflectRows applies the Householder reflection I - tau*v*vᵀ from the
+// left to rows [r0, r0+len(v)) of a, restricted to columns [c0, c1).
+func reflectRows(a *Matrix, v []T, tau T, r0, c0, c1 int) {
+	for j := c0; j < c1; j++ {
+		var s T
+		for i, vi := range v {
+			s += vi * a[r0+i, j]
+		}
+		s *= tau
+		for i, vi := range v {
+			a[r0+i, j] -= s * vi
+		}
+	}
+}
+
+// reflectCols applies the Householder reflection I - tau*v*vᵀ from the
+// right to columns [c0, c0+len(v)) of a, restricted to rows [r0, r1).
+func reflectCols(a *Matrix, v []T, tau T, c0, r0, r1 int) {
+	for i := r0; i < r1; i++ {
+		var s T
+		for j, vj := range v {
+			s += a[i, c0+j] * vj
+		}
+		s *= tau
+		for j, vj := range v {
+			a[i, c0+j] -= s * vj
+		}
+	}
+}
+
+// EigenSym returns the eigenvalues of the symmetric matrix a in
+// increasing order, and the orthogonal matrix whose columns are the
+// corresponding unit eigenvectors, such that a = v*diag(values)*vᵀ.
+// The symmetry of a is not checked. The algorithm is the Householder
+// reduction to tridiagonal form followed by the implicit QL iteration
+// (the EISPACK routines tred2 and tql2).
+func (a *Matrix) EigenSym() (values *Vector, vectors *Matrix, err error) {
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	v := a.Copy()
+	d := make([]T, n)
+	e := make([]T, n)
+	if n > 0 {
+		tridiagonalize(v, d, e)
+		if err := tridiagonalQL(v, d, e); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// sort by eigenvalue (selection sort; n swaps of columns)
+	for i := 0; i < n; i++ {
+		k := i
+		for j := i + 1; j < n; j++ {
+			if d[j] < d[k] {
+				k = j
+			}
+		}
+		if k != i {
+			d[i], d[k] = d[k], d[i]
+			for l := 0; l < n; l++ {
+				t := v[l, i]
+				v[l, i] = v[l, k]
+				v[l, k] = t
+			}
+		}
+	}
+	values = NewVector(n)
+	for i, x := range d {
+		values[i] = x
+	}
+	return values, v, nil
+}
+
+// tridiagonalize reduces the symmetric matrix v to tridiagonal form
+// by orthogonal similarity transformations, overwriting v with the
+// accumulated transformation. On return, d holds the diagonal and
+// e[1:] the subdiagonal of the tridiagonal matrix.
+func tridiagonalize(v *Matrix, d, e []T) {
+	n := len(d)
+	for j := 0; j < n; j++ {
+		d[j] = v[n-1, j]
+	}
+	for i := n - 1; i > 0; i-- {
+		// scale to avoid under/overflow
+		var scale, h T
+		for k := 0; k < i; k++ {
+			scale += abs(d[k])
+		}
+		if scale == 0 {
+			e[i] = d[i-1]
+			for j := 0; j < i; j++ {
+				d[j] = v[i-1, j]
+				v[i, j] = 0
+				v[j, i] = 0
+			}
+			d[i] = h
+			continue
+		}
+
+		// generate the Householder vector
+		for k := 0; k < i; k++ {
+			d[k] /= scale
+			h += d[k] * d[k]
+		}
+		f := d[i-1]
+		g := sqrt(h)
+		if f > 0 {
+			g = -g
+		}
+		e[i] = scale * g
+		h -= f * g
+		d[i-1] = f - g
+		for j := 0; j < i; j++ {
+			e[j] = 0
+		}
+
+		// apply the similarity transformation to the remaining columns
+		for j := 0; j < i; j++ {
+			f = d[j]
+			v[j, i] = f
+			g = e[j] + v[j, j]*f
+			for k := j + 1; k < i; k++ {
+				g += v[k, j] * d[k]
+				e[k] += v[k, j] * f
+			}
+			e[j] = g
+		}
+		f = 0
+		for j := 0; j < i; j++ {
+			e[j] /= h
+			f += e[j] * d[j]
+		}
+		hh := f / (h + h)
+		for j := 0; j < i; j++ {
+			e[j] -= hh * d[j]
+		}
+		for j := 0; j < i; j++ {
+			f, g = d[j], e[j]
+			for k := j; k < i; k++ {
+				v[k, j] -= f*e[k] + g*d[k]
+			}
+			d[j] = v[i-1, j]
+			v[i, j] = 0
+		}
+		d[i] = h
+	}
+
+	// accumulate the transformations
+	for i := 0; i < n-1; i++ {
+		v[n-1, i] = v[i, i]
+		v[i, i] = 1
+		if h := d[i+1]; h != 0 {
+			for k := 0; k <= i; k++ {
+				d[k] = v[k, i+1] / h
+			}
+			for j := 0; j <= i; j++ {
+				var g T
+				for k := 0; k <= i; k++ {
+					g += v[k, i+1] * v[k, j]
+				}
+				for k := 0; k <= i; k++ {
+					v[k, j] -= g * d[k]
+				}
+			}
+		}
+		for k := 0; k <= i; k++ {
+			v[k, i+1] = 0
+		}
+	}
+	for j := 0; j < n; j++ {
+		d[j] = v[n-1, j]
+		v[n-1, j] = 0
+	}
+	v[n-1, n-1] = 1
+	e[0] = 0
+}
+
+// tridiagonalQL computes the eigenvalues and eigenvectors of the
+// symmetric tridiagonal matrix with diagonal d and subdiagonal e[1:]
+// with the implicit QL method, overwriting d with the (unsorted)
+// eigenvalues and applying the transformations to the columns of v.
+func tridiagonalQL(v *Matrix, d, e []T) error {
+	n := len(d)
+	for i := 1; i < n; i++ {
+		e[i-1] = e[i]
+	}
+	e[n-1] = 0
+
+	var f, tst1 T
+	for l, total := 0, 0; l < n; l++ {
+		// find a small subdiagonal element
+		if t := abs(d[l]) + abs(e[l]); t > tst1 {
+			tst1 = t
+		}
+		m := l
+		for m < n-1 && abs(e[m]) > epsilon*tst1 {
+			m++
+		}
+
+		// iterate until e[l] is negligible
+		for m > l && abs(e[l]) > epsilon*tst1 {
+			if total >= 30*n {
+				return ErrNoConvergence
+			}
+			total++
+
+			// compute the implicit shift
+			g := d[l]
+			p := (d[l+1] - g) / (2 * e[l])
+			r := T(math.Hypot(float64(p), 1))
+			if p < 0 {
+				r = -r
+			}
+			d[l] = e[l] / (p + r)
+			d[l+1] = e[l] * (p + r)
+			dl1 := d[l+1]
+			h := g - d[l]
+			for i := l + 2; i < n; i++ {
+				d[i] -= h
+			}
+			f += h
+
+			// implicit QL transformation
+			p = d[m]
+			c, c2, c3 := T(1), T(1), T(1)
+			el1 := e[l+1]
+			var s, s2 T
+			for i := m - 1; i >= l; i-- {
+				c3, c2, s2 = c2, c, s
+				g = c * e[i]
+				h = c * p
+				r = T(math.Hypot(float64(p), float64(e[i])))
+				e[i+1] = s * r
+				s, c = e[i]/r, p/r
+				p = c*d[i] - s*g
+				d[i+1] = h + s*(c*g+s*d[i])
+				for k := 0; k < n; k++ {
+					h = v[k, i+1]
+					v[k, i+1] = s*v[k, i] + c*h
+					v[k, i] = c*v[k, i] - s*h
+				}
+			}
+			p = -s * s2 * c3 * el1 * e[l] / dl1
+			e[l] = s * p
+			d[l] = c * p
+		}
+		d[l] += f
+		e[l] = 0
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "math/rand"
+
+// A Which selects the eigenvalues computed by EigsSparse.
+type Which int
+
+const (
+	WhichLargest          Which = iota // largest (algebraic) eigenvalues
+	WhichSmallest                      // smallest (algebraic) eigenvalues
+	WhichLargestMagnitude              // eigenvalues largest in absolute value
+)
+
+// less reports whether the eigenvalue x is to be preferred over y.
+func (w Which) less(x, y T) bool {
+	switch w {
+	case WhichLargest:
+		return x > y
+	case WhichSmallest:
+		return x < y
+	case WhichLargestMagnitude:
+		return abs(x) > abs(y)
+	}
+	panic("invalid eigenvalue selection")
+}
+
+// EigsSparse returns k eigenvalues of the symmetric operator op,
+// selected by which and in that order (for instance, decreasing for
+// WhichLargest), and the n×k matrix of corresponding orthonormal
+// eigenvectors. The eigenpairs are computed with the thick-restart
+// Lanczos method (Wu and Simon, 2000) with full reorthogonalization,
+// which needs only products of op with vectors and memory for a few
+// times k vectors of length n; op is typically a large sparse matrix
+// such as a *CSR or *SymCSR. If the eigenpairs do not converge, the
+// result is the current approximation and ErrNoConvergence. The result
+// is deterministic.
+func EigsSparse(op LinearOperator, k int, which Which) (values *Vector, vectors *Matrix, err error) {
+	const (
+		tol         = 1e-10
+		maxRestarts = 1000
+	)
+	n, m := op.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	if k < 1 || k > n {
+		panic("invalid number of eigenvalues")
+	}
+	which.less(0, 0) // validate which
+	ncv := 2*k + 20
+	if ncv > n {
+		ncv = n
+	}
+
+	// Lanczos basis q and projection h = qᵀ*op*q
+	r := rand.New(rand.NewSource(1))
+	q := NewMatrix(n, ncv)
+	h := NewMatrix(ncv, ncv)
+	randomOrthogonal(q.Col(0), q, 0, r)
+	for j, restart := 0, 0; ; restart++ {
+		w := lanczos(op, q, h, j, r)
+		rnorm := sqrt(w * w)
+		theta, s, err := h.EigenSym()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// order the Ritz values by preference (insertion sort of indices)
+		idx := make([]int, ncv)
+		for i := range idx {
+			idx[i] = i
+		}
+		for i := 1; i < ncv; i++ {
+			for l := i; l > 0 && which.less(theta[idx[l]], theta[idx[l-1]]); l-- {
+				idx[l], idx[l-1] = idx[l-1], idx[l]
+			}
+		}
+
+		// the residual of the Ritz pair (θ, q*s) is ‖w‖ * |s[ncv-1]|
+		var scale T
+		for i := 0; i < ncv; i++ {
+			if t := abs(theta[i]); t > scale {
+				scale = t
+			}
+		}
+		converged := true
+		for _, i := range idx[:k] {
+			if rnorm*abs(s[ncv-1, i]) > tol*scale {
+				converged = false
+			}
+		}
+		if converged || restart == maxRestarts {
+			values = NewVector(k)
+			sk := NewMatrix(ncv, k)
+			for l, i := range idx[:k] {
+				values[l] = theta[i]
+				copyVector(sk.Col(l), s.Col(i))
+			}
+			vectors = q * sk
+			if !converged {
+				err = ErrNoConvergence
+			}
+			return values, vectors, err
+		}
+
+		// thick restart: keep the j most wanted Ritz vectors and
+		// continue with the residual direction
+		j = k + (ncv-k)/2
+		if j > ncv-1 {
+			j = ncv - 1
+		}
+		sj := NewMatrix(ncv, j)
+		for l, i := range idx[:j] {
+			copyVector(sj.Col(l), s.Col(i))
+		}
+		y := q * sj
+		for i := 0; i < ncv; i++ {
+			for l := 0; l < ncv; l++ {
+				h[i, l] = 0
+			}
+		}
+		for l, i := range idx[:j] {
+			copyVector(q.Col(l), y.Col(l))
+			h[l, l] = theta[i]
+		}
+		qj := q.Col(j)
+		copyVector(qj, w)
+		if !orthogonalizeAgainst(qj, q, j) {
+			randomOrthogonal(qj, q, j, r)
+		}
+	}
+}
+
+// lanczos extends the orthonormal basis q of a Krylov space of the
+// symmetric operator op from j0+1 to all columns, filling in the
+// projection h = qᵀ*op*q, and returns the residual w = op*q - q*h of
+// the last column. Every new vector is orthogonalized against all
+// previous ones (twice, which is enough; see Parlett, "The Symmetric
+// Eigenvalue Problem", Section 6.9), so the columns of h can simply be
+// the coefficients of the orthogonalization, and h remains valid
+// after thick restarts. If the Krylov space becomes invariant, the
+// process continues with a random vector drawn from r.
+func lanczos(op LinearOperator, q, h *Matrix, j0 int, r *rand.Rand) (w *Vector) {
+	n, ncv := q.Len()
+	for j := j0; j < ncv; j++ {
+		w = op.MulVec(q.Col(j))
+		before := sqrt(w * w)
+		for pass := 0; pass < 2; pass++ {
+			for l := 0; l <= j; l++ {
+				ql := q.Col(l)
+				c := ql * w
+				for i := 0; i < n; i++ {
+					w[i] -= c * ql[i]
+				}
+				h[l, j] += c
+			}
+		}
+		for l := 0; l < j; l++ {
+			h[j, l] = h[l, j]
+		}
+		if j == ncv-1 {
+			break
+		}
+		qn := q.Col(j + 1)
+		copyVector(qn, w)
+		if norm := sqrt(w * w); norm <= epsilon*before || !orthogonalizeAgainst(qn, q, j+1) {
+			// invariant subspace found
+			randomOrthogonal(qn, q, j+1, r)
+		}
+	}
+	return
+}
+
+// orthogonalizeAgainst orthogonalizes w against the first k columns of
+// q (twice) and normalizes it. The result is false if w is (nearly)
+// in their span.
+func orthogonalizeAgainst(w *Vector, q *Matrix, k int) bool {
+	n := w.Len()
+	before := sqrt(w * w)
+	for pass := 0; pass < 2; pass++ {
+		for l := 0; l < k; l++ {
+			ql := q.Col(l)
+			h := ql * w
+			for i := 0; i < n; i++ {
+				w[i] -= h * ql[i]
+			}
+		}
+	}
+	norm := sqrt(w * w)
+	if norm <= 1e-10*before || norm == 0 {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		w[i] /= norm
+	}
+	return true
+}
+
+// randomOrthogonal overwrites w with a random unit vector drawn from
+// r that is orthogonal to the first k < n columns of q.
+func randomOrthogonal(w *Vector, q *Matrix, k int, r *rand.Rand) {
+	for {
+		for i := 0; i < w.Len(); i++ {
+			w[i] = T(r.NormFloat64())
+		}
+		if orthogonalizeAgainst(w, q, k) {
+			return
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// Element-wise arithmetic. The operands of binary operations must have
+// the same shape; the results are new matrices and vectors.
+
+func (a *Matrix) + (b *Matrix) *Matrix { return a.Add(b) }
+func (a *Matrix) - (b *Matrix) *Matrix { return a.Sub(b) }
+
+// Add returns the sum a+b.
+func (a *Matrix) Add(b *Matrix) *Matrix {
+	n, m := a.Len()
+	return AddTo(NewMatrix(n, m), a, b)
+}
+
+// Sub returns the difference a-b.
+func (a *Matrix) Sub(b *Matrix) *Matrix {
+	n, m := a.Len()
+	return SubTo(NewMatrix(n, m), a, b)
+}
+
+// MulElem returns the element-wise (Hadamard) product of a and b.
+func (a *Matrix) MulElem(b *Matrix) *Matrix {
+	n, m := a.Len()
+	return MulElemTo(NewMatrix(n, m), a, b)
+}
+
+// Scale returns the product alpha*a.
+func (a *Matrix) Scale(alpha T) *Matrix {
+	n, m := a.Len()
+	return ScaleTo(NewMatrix(n, m), alpha, a)
+}
+
+func (x *Vector) + (y *Vector) *Vector { return x.Add(y) }
+func (x *Vector) - (y *Vector) *Vector { return x.Sub(y) }
+
+// Add returns the sum x+y.
+func (x *Vector) Add(y *Vector) *Vector {
+	return x.elementwise(y, func(a, b T) T { return a + b })
+}
+
+// Sub returns the difference x-y.
+func (x *Vector) Sub(y *Vector) *Vector {
+	return x.elementwise(y, func(a, b T) T { return a - b })
+}
+
+// MulElem returns the element-wise (Hadamard) product of x and y.
+// (The operator * is the dot product.)
+func (x *Vector) MulElem(y *Vector) *Vector {
+	return x.elementwise(y, func(a, b T) T { return a * b })
+}
+
+// Scale returns the product alpha*x.
+func (x *Vector) Scale(alpha T) *Vector {
+	z := NewVector(x.Len())
+	for i := 0; i < x.Len(); i++ {
+		z[i] = alpha * x[i]
+	}
+	return z
+}
+
+// AXPY overwrites y with y + alpha*x in place (BLAS AXPY) and returns y.
+func (y *Vector) AXPY(alpha T, x *Vector) *Vector {
+	if x.Len() != y.Len() {
+		panic("incompatible vector lengths")
+	}
+	if x.stride == 1 && y.stride == 1 {
+		axpyUnitary(alpha, x.array[x.off:][:x.len], y.array[y.off:][:y.len])
+		return y
+	}
+	for i := 0; i < y.Len(); i++ {
+		y[i] += alpha * x[i]
+	}
+	return y
+}
+
+// Outer returns the outer product x*yᵀ, the matrix with elements
+// x[i]*y[j].
+func (x *Vector) Outer(y *Vector) *Matrix {
+	n, m := x.Len(), y.Len()
+	a := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			a[i, j] = x[i] * y[j]
+		}
+	}
+	return a
+}
+
+// Cross returns the cross product x×y of the 3-element vectors x and y.
+func (x *Vector) Cross(y *Vector) *Vector {
+	if x.Len() != 3 || y.Len() != 3 {
+		panic("incompatible vector lengths")
+	}
+	z := NewVector(3)
+	z[0] = x[1]*y[2] - x[2]*y[1]
+	z[1] = x[2]*y[0] - x[0]*y[2]
+	z[2] = x[0]*y[1] - x[1]*y[0]
+	return z
+}
+
+func (x *Vector) elementwise(y *Vector, f func(a, b T) T) *Vector {
+	if x.Len() != y.Len() {
+		panic("incompatible vector lengths")
+	}
+	z := NewVector(x.Len())
+	for i := 0; i < x.Len(); i++ {
+		z[i] = f(x[i], y[i])
+	}
+	return z
+}
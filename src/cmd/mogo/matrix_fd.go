@@ -0,0 +1,116 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// Finite-difference operators on uniform grids with spacing h.
+//
+// The gradient operators use forward differences between neighboring
+// grid points, one row per grid edge. The Laplacians are the negative
+// discrete Laplacian -Δ = DᵀD for the corresponding gradient D, so
+// they are symmetric positive (semi)definite; with h = 1 they are the
+// graph Laplacians D - A of the grid. Both are returned in CSR format;
+// use Dense to obtain the dense matrices.
+
+// A Boundary selects the boundary condition of a finite-difference
+// operator.
+type Boundary int
+
+const (
+	BoundaryDirichlet Boundary = iota // zero values outside the grid
+	BoundaryNeumann                   // zero derivative across the boundary
+	BoundaryPeriodic                  // the grid wraps around
+)
+
+// edges1D returns the edges (a[k], b[k]) of a 1D grid with n points
+// under boundary condition bc. An index of -1 denotes a point outside
+// the grid, whose value is zero.
+func edges1D(n int, bc Boundary) (a, b []int) {
+	if n < 0 {
+		panic("invalid length")
+	}
+	for i := 0; i+1 < n; i++ {
+		a = append(a, i)
+		b = append(b, i+1)
+	}
+	switch bc {
+	case BoundaryDirichlet:
+		a = append([]int{-1}, a...)
+		b = append([]int{0}, b...)
+		a = append(a, n-1)
+		b = append(b, -1)
+		if n == 0 {
+			a, b = nil, nil
+		}
+	case BoundaryNeumann:
+		// no boundary edges
+	case BoundaryPeriodic:
+		if n > 0 {
+			a = append(a, n-1)
+			b = append(b, 0)
+		}
+	default:
+		panic("invalid boundary condition")
+	}
+	return
+}
+
+// Gradient1D returns the forward-difference operator on a 1D grid
+// with n points: row k of the result computes (u[b]-u[a])/h for the
+// k'th edge (a, b) of the grid. It has n+1 rows for Dirichlet, n-1
+// rows for Neumann, and n rows for periodic boundary conditions.
+func Gradient1D(n int, h T, bc Boundary) *CSR {
+	a, b := edges1D(n, bc)
+	t := &COO{len: Shape{len(a), n}}
+	for k := range a {
+		if a[k] >= 0 {
+			t.Append(k, a[k], -1/h)
+		}
+		if b[k] >= 0 {
+			t.Append(k, b[k], 1/h)
+		}
+	}
+	return t.CSR()
+}
+
+// Laplacian1D returns the n×n negative discrete Laplacian on a 1D grid
+// with n points, the matrix tridiag(-1, 2, -1)/h² adjusted for the
+// boundary condition bc.
+func Laplacian1D(n int, h T, bc Boundary) *CSR {
+	a, b := edges1D(n, bc)
+	w := 1 / (h * h)
+	t := &COO{len: Shape{n, n}}
+	for k := range a {
+		if a[k] >= 0 {
+			t.Append(a[k], a[k], w)
+		}
+		if b[k] >= 0 {
+			t.Append(b[k], b[k], w)
+		}
+		if a[k] >= 0 && b[k] >= 0 {
+			t.Append(a[k], b[k], -w)
+			t.Append(b[k], a[k], -w)
+		}
+	}
+	return t.CSR()
+}
+
+// Gradient2D returns the forward-difference operators along the rows
+// and the columns of an n×m grid. Grid point (i, j) has index i*m+j,
+// so a grid function laid out as a row-major n×m Matrix is a vector
+// the operators can be applied to. The result di differentiates in
+// the i direction and dj in the j direction.
+func Gradient2D(n, m int, h T, bc Boundary) (di, dj *CSR) {
+	di = kron(Gradient1D(n, h, bc), eye(m))
+	dj = kron(eye(n), Gradient1D(m, h, bc))
+	return
+}
+
+// Laplacian2D returns the nm×nm negative discrete Laplacian (5-point
+// stencil) on an n×m grid, with grid point (i, j) at index i*m+j.
+func Laplacian2D(n, m int, h T, bc Boundary) *CSR {
+	return addCSR(kron(Laplacian1D(n, h, bc), eye(m)), kron(eye(n), Laplacian1D(m, h, bc)))
+}
@@ -0,0 +1,206 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "math"
+
+// FFT2 returns the 2D discrete Fourier transform of a,
+//
+//	c[k, l] = Σ a[i, j] * exp(-2πi (k*i/n + l*j/m)),
+//
+// computed by 1D FFTs of all rows and then all columns. Any size is
+// supported; powers of 2 are fastest.
+func FFT2(a *CMatrix) *CMatrix {
+	return fft2(a, false)
+}
+
+// IFFT2 returns the inverse 2D discrete Fourier transform of a,
+// including the normalization by 1/(n*m), so that IFFT2(FFT2(a))
+// equals a up to rounding.
+func IFFT2(a *CMatrix) *CMatrix {
+	c := fft2(a, true)
+	n, m := c.Len()
+	s := complex(1/float64(n*m), 0)
+	for i := range c.array {
+		c.array[i] *= s
+	}
+	return c
+}
+
+func fft2(a *CMatrix, inverse bool) *CMatrix {
+	c := a.Copy()
+	n, m := c.Len()
+	for i := 0; i < n; i++ {
+		// rows are contiguous
+		fft(c.array[i*m:(i+1)*m], inverse)
+	}
+	buf := make([]complex128, n)
+	for j := 0; j < m; j++ {
+		for i := range buf {
+			buf[i] = c.array[i*m+j]
+		}
+		fft(buf, inverse)
+		for i := range buf {
+			c.array[i*m+j] = buf[i]
+		}
+	}
+	return c
+}
+
+// fft overwrites x with its (unnormalized) discrete Fourier transform,
+// or with the inverse transform (without the 1/n factor) if inverse is
+// set. Lengths that are not a power of 2 use Bluestein's algorithm.
+func fft(x []complex128, inverse bool) {
+	n := len(x)
+	if n <= 1 {
+		return
+	}
+	if n&(n-1) == 0 {
+		fftRadix2(x, inverse)
+		return
+	}
+
+	// Bluestein: with w[k] = exp(∓πi k²/n), X[k] = w[k] * Σ (x[j]*w[j]) * conj(w[k-j]),
+	// a convolution that is evaluated with power-of-2 FFTs
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	w := make([]complex128, n)
+	for k := range w {
+		// k² mod 2n keeps the angle small and accurate
+		s, c := math.Sincos(sign * math.Pi * float64(k*k%(2*n)) / float64(n))
+		w[k] = complex(c, s)
+	}
+	size := 1
+	for size < 2*n-1 {
+		size <<= 1
+	}
+	u := make([]complex128, size)
+	v := make([]complex128, size)
+	for k := 0; k < n; k++ {
+		u[k] = x[k] * w[k]
+		v[k] = conj(w[k])
+		if k > 0 {
+			v[size-k] = v[k]
+		}
+	}
+	fftRadix2(u, false)
+	fftRadix2(v, false)
+	for k := range u {
+		u[k] *= v[k]
+	}
+	fftRadix2(u, true)
+	s := complex(1/float64(size), 0)
+	for k := range x {
+		x[k] = w[k] * u[k] * s
+	}
+}
+
+// fftRadix2 is fft for lengths that are a power of 2, using the
+// iterative Cooley-Tukey algorithm.
+func fftRadix2(x []complex128, inverse bool) {
+	n := len(x)
+	// bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	tw := make([]complex128, n/2)
+	for k := range tw {
+		s, c := math.Sincos(sign * 2 * math.Pi * float64(k) / float64(n))
+		tw[k] = complex(c, s)
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half, step := size/2, n/size
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				t := tw[k*step] * x[start+k+half]
+				x[start+k+half] = x[start+k] - t
+				x[start+k] += t
+			}
+		}
+	}
+}
+
+func conj(z complex128) complex128 { return complex(real(z), -imag(z)) }
+
+// Correlate2DFFT returns the 2D cross-correlation of a with kernel
+// with zero padding, the same result as Correlate2D with
+// &CorrelateOptions{Mode: mode} up to rounding, but computed in the
+// frequency domain in O(N log N) time for N = (n+kh)*(m+kw) instead
+// of O(n*m*kh*kw). It pays off for large kernels. For convolution
+// (with a flipped kernel), use kernel.Rotate90(2).
+func (a *Matrix) Correlate2DFFT(kernel *Matrix, mode CorrelateMode) *Matrix {
+	n, m := a.Len()
+	kh, kw := kernel.Len()
+
+	// the full correlation is the convolution with the flipped kernel;
+	// the other modes are windows of it, offset by (oi, oj)
+	var cn, cm, oi, oj int
+	switch mode {
+	case CorrelateFull:
+		cn, cm = n+kh-1, m+kw-1
+	case CorrelateSame:
+		cn, cm = n, m
+		oi, oj = kh-1-(kh-1)/2, kw-1-(kw-1)/2
+	case CorrelateValid:
+		cn, cm = n-kh+1, m-kw+1
+		oi, oj = kh-1, kw-1
+	default:
+		panic("invalid correlation mode")
+	}
+	if kh == 0 || kw == 0 || n == 0 || m == 0 || cn < 0 || cm < 0 {
+		if mode == CorrelateSame {
+			return NewMatrix(n, m)
+		}
+		return NewMatrix(0, 0)
+	}
+
+	// zero-pad to power-of-2 sizes that hold the full convolution
+	pn, pm := 1, 1
+	for pn < n+kh-1 {
+		pn <<= 1
+	}
+	for pm < m+kw-1 {
+		pm <<= 1
+	}
+	fa, fk := NewCMatrix(pn, pm), NewCMatrix(pn, pm)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			fa[i, j] = complex(float64(a[i, j]), 0)
+		}
+	}
+	for u := 0; u < kh; u++ {
+		for v := 0; v < kw; v++ {
+			fk[u, v] = complex(float64(kernel[kh-1-u, kw-1-v]), 0)
+		}
+	}
+	fa, fk = FFT2(fa), FFT2(fk)
+	for i := range fa.array {
+		fa.array[i] *= fk.array[i]
+	}
+	full := IFFT2(fa)
+
+	c := NewMatrix(cn, cm)
+	for i := 0; i < cn; i++ {
+		for j := 0; j < cm; j++ {
+			c[i, j] = T(real(full[i+oi, j+oj]))
+		}
+	}
+	return c
+}
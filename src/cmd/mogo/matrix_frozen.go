@@ -0,0 +1,36 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A Frozen is a read-only matrix. Since none of its methods modify it
+// and its storage is not shared with any Matrix, a Frozen may be used
+// by multiple goroutines concurrently without further synchronization.
+//
+// In contrast, a Matrix is only safe for concurrent reads as long as
+// no goroutine writes to it or to any view (Row, Col, Transpose, ...)
+// sharing its storage.
+type Frozen struct {
+	m *Matrix
+}
+
+// Freeze returns a read-only snapshot of a. Later changes to a do not
+// affect the result.
+func (a *Matrix) Freeze() *Frozen {
+	return &Frozen{a.Copy()}
+}
+
+func (f *Frozen) Len() (int, int) { return f.m.Len() }
+func (f *Frozen) [] (i, j int) T  { return f.m[i, j] }
+
+// Row returns a copy of row i.
+func (f *Frozen) Row(i int) *Vector { return f.m.Row(i).Copy() }
+
+// Col returns a copy of column j.
+func (f *Frozen) Col(j int) *Vector { return f.m.Col(j).Copy() }
+
+// Matrix returns a modifiable copy of f.
+func (f *Frozen) Matrix() *Matrix { return f.m.Copy() }
@@ -0,0 +1,83 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// Missing values are represented by NaN elements, as produced for
+// empty fields by CSVReader.
+
+func isNaN(x T) bool { return x != x }
+
+// Missing returns the mask of the missing values of a: a matrix of
+// the same size with 1 where a has a NaN and 0 elsewhere.
+func (a *Matrix) Missing() *Matrix {
+	n, m := a.Len()
+	mask := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			if isNaN(a[i, j]) {
+				mask[i, j] = 1
+			}
+		}
+	}
+	return mask
+}
+
+// ImputeConstant returns a copy of a with each missing value replaced
+// by v.
+func (a *Matrix) ImputeConstant(v T) *Matrix {
+	n, m := a.Len()
+	c := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			x := a[i, j]
+			if isNaN(x) {
+				x = v
+			}
+			c[i, j] = x
+		}
+	}
+	return c
+}
+
+// ImputeMean returns a copy of a with each missing value replaced by
+// the mean of the values present in its column (for axis 0) or row
+// (for axis 1), the axis convention of Scaler. A column (or row)
+// without any values present stays missing; follow up with
+// ImputeConstant if needed.
+func (a *Matrix) ImputeMean(axis int) *Matrix {
+	c := a.Copy()
+	b := c
+	switch axis {
+	case 0:
+		b = c.Transpose()
+	case 1:
+	default:
+		panic("invalid axis")
+	}
+	n, m := b.Len()
+	for k := 0; k < n; k++ {
+		x := b.Row(k)
+		var sum T
+		cnt := 0
+		for i := 0; i < m; i++ {
+			if !isNaN(x[i]) {
+				sum += x[i]
+				cnt++
+			}
+		}
+		if cnt == 0 || cnt == m {
+			continue
+		}
+		mean := sum / T(cnt)
+		for i := 0; i < m; i++ {
+			if isNaN(x[i]) {
+				x[i] = mean
+			}
+		}
+	}
+	return c
+}
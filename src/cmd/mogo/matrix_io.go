@@ -0,0 +1,351 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+)
+
+// Binary matrix format, version 1: the 4-byte magic "GOMV", followed
+// by the format version and the length of the remaining header as
+// little-endian uint16 values, and the header itself:
+//
+//	dtype        uint8  element type (DType)
+//	layout       uint8  element order (Layout)
+//	byte order   uint8  0 for little-endian, 1 for big-endian elements
+//	compression  uint8  compression of the element data (Compression)
+//	rows, cols   uint64 little-endian matrix size
+//
+// The elements follow, possibly compressed. Readers accept any version
+// from 1 on and skip header bytes they do not know, so later versions
+// may append header fields without breaking older readers.
+//
+// Version 0 of the format, as written by older versions of Save, was
+// the 4-byte magic "GOMX", followed by the number of rows and columns
+// as little-endian uint32 values and the elements in row-major order
+// as little-endian float64 values. Load still reads it.
+const (
+	binaryMagic   = "GOMV"
+	binaryVersion = 1
+	headerLen     = 20 // length of the version 1 header
+
+	binaryMagicV0 = "GOMX"
+)
+
+var (
+	errFormat      = errors.New("invalid matrix format")
+	errVersion     = errors.New("unsupported matrix format version")
+	errCompression = errors.New("unsupported matrix compression")
+)
+
+// A DType is the element type of a stored matrix.
+type DType uint8
+
+const (
+	Float64 DType = 1 + iota
+	Float32       // converted from and to T, possibly losing precision
+)
+
+func (d DType) size() int {
+	switch d {
+	case Float64:
+		return 8
+	case Float32:
+		return 4
+	}
+	return 0
+}
+
+// A Layout is the order of the elements of a stored matrix.
+type Layout uint8
+
+const (
+	RowMajor Layout = iota
+	ColMajor
+)
+
+// A Compression is the compression method of stored matrix elements.
+type Compression uint8
+
+const (
+	CompressNone  Compression = iota
+	CompressFlate             // DEFLATE (RFC 1951)
+
+	// Code 2 is reserved for zstd, which is not implemented: reading
+	// or writing it fails with errCompression.
+	compressZstd
+)
+
+// SaveOptions controls the representation written by SaveWith.
+// A nil *SaveOptions is equivalent to the zero value, which selects
+// uncompressed little-endian float64 elements in row-major order.
+type SaveOptions struct {
+	DType       DType            // 0 means Float64
+	Layout      Layout           // element order
+	ByteOrder   binary.ByteOrder // nil means binary.LittleEndian
+	Compression Compression
+}
+
+// Save writes a in the binary matrix format to w, using the
+// default SaveOptions.
+func (a *Matrix) Save(w io.Writer) error {
+	return a.SaveWith(w, nil)
+}
+
+// SaveWith writes a in the binary matrix format to w, with the
+// representation selected by opts.
+func (a *Matrix) SaveWith(w io.Writer, opts *SaveOptions) error {
+	if opts == nil {
+		opts = new(SaveOptions)
+	}
+	dtype, order := opts.DType, opts.ByteOrder
+	if dtype == 0 {
+		dtype = Float64
+	}
+	if dtype.size() == 0 || opts.Layout > ColMajor {
+		return errFormat
+	}
+	if order == nil {
+		order = binary.LittleEndian
+	}
+	var bigEndian uint8
+	if order == binary.BigEndian {
+		bigEndian = 1
+	}
+
+	n, m := a.Len()
+	bw := bufio.NewWriter(w)
+	bw.WriteString(binaryMagic)
+	hdr := struct {
+		Version, Len                          uint16
+		DType, Layout, BigEndian, Compression uint8
+		Rows, Cols                            uint64
+	}{binaryVersion, headerLen, uint8(dtype), uint8(opts.Layout), bigEndian, uint8(opts.Compression), uint64(n), uint64(m)}
+	if err := binary.Write(bw, binary.LittleEndian, &hdr); err != nil {
+		return err
+	}
+
+	var ew io.Writer = bw
+	var fw *flate.Writer
+	switch opts.Compression {
+	case CompressNone:
+	case CompressFlate:
+		fw, _ = flate.NewWriter(bw, flate.DefaultCompression)
+		ew = fw
+	default:
+		return errCompression
+	}
+	if opts.Layout == ColMajor {
+		a = a.Transpose()
+		n, m = m, n
+	}
+	buf := make([]byte, dtype.size())
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			if dtype == Float32 {
+				order.PutUint32(buf, math.Float32bits(float32(a[i, j])))
+			} else {
+				order.PutUint64(buf, math.Float64bits(float64(a[i, j])))
+			}
+			if _, err := ew.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	if fw != nil {
+		if err := fw.Close(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Load reads a matrix in the binary matrix format, of any supported
+// version and representation, from r. If r is a *bufio.Reader, Load
+// does not read beyond the end of the matrix.
+func Load(r io.Reader) (*Matrix, error) {
+	br := bufio.NewReader(r)
+	var magic [len(binaryMagic)]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil {
+		return nil, err
+	}
+	switch string(magic[:]) {
+	case binaryMagic:
+		return load(br)
+	case binaryMagicV0:
+		return loadV0(br)
+	}
+	return nil, errFormat
+}
+
+// load reads a matrix in version 1 or later of the binary matrix
+// format from br, after the magic.
+func load(br *bufio.Reader) (*Matrix, error) {
+	var pre [2]uint16
+	if err := binary.Read(br, binary.LittleEndian, pre[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if pre[0] < binaryVersion {
+		return nil, errVersion
+	}
+	if pre[1] < headerLen {
+		return nil, errFormat
+	}
+	var hdr struct {
+		DType, Layout, BigEndian, Compression uint8
+		Rows, Cols                            uint64
+	}
+	if err := binary.Read(br, binary.LittleEndian, &hdr); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if _, err := io.CopyN(ioutil.Discard, br, int64(pre[1]-headerLen)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	dtype := DType(hdr.DType)
+	if dtype.size() == 0 || Layout(hdr.Layout) > ColMajor || hdr.BigEndian > 1 {
+		return nil, errFormat
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if hdr.BigEndian == 1 {
+		order = binary.BigEndian
+	}
+	if hdr.Rows > math.MaxInt32 || hdr.Cols > math.MaxInt32 {
+		return nil, errFormat
+	}
+	n, m := int(hdr.Rows), int(hdr.Cols)
+	if !sizeOK(n, m, dtype.size()) {
+		return nil, errFormat
+	}
+
+	var er io.Reader = br
+	var fr io.ReadCloser
+	switch Compression(hdr.Compression) {
+	case CompressNone:
+	case CompressFlate:
+		fr = flate.NewReader(br)
+		er = fr
+	default:
+		return nil, errCompression
+	}
+
+	data, err := readElements(er, n*m, dtype, order)
+	if err != nil {
+		return nil, err
+	}
+	a := WrapMatrix(data, n, m, m)
+	if Layout(hdr.Layout) == ColMajor {
+		a = TransposeCopyTo(NewMatrix(n, m), WrapMatrix(data, m, n, n))
+	}
+	if fr != nil {
+		// consume the end of the compressed stream
+		if _, err := io.Copy(ioutil.Discard, fr); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// loadV0 reads a matrix in version 0 of the binary matrix format
+// from br, after the magic.
+func loadV0(br *bufio.Reader) (*Matrix, error) {
+	var hdr [2]uint32
+	if err := binary.Read(br, binary.LittleEndian, hdr[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	n, m := int(hdr[0]), int(hdr[1])
+	if !sizeOK(n, m, Float64.size()) {
+		return nil, errFormat
+	}
+	data, err := readElements(br, n*m, Float64, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	return WrapMatrix(data, n, m, m), nil
+}
+
+// sizeOK reports whether an n×m matrix of elements of size bytes each
+// has a size that can be represented.
+func sizeOK(n, m, size int) bool {
+	const maxInt = int(^uint(0) >> 1)
+	return n >= 0 && m >= 0 && (m == 0 || n <= maxInt/size/m)
+}
+
+// loadChunk is the number of elements for which readElements allocates
+// storage before reading them.
+const loadChunk = 1 << 16
+
+// readElements reads count elements of the given type and byte order
+// from r. The result grows as the elements arrive rather than being
+// allocated upfront, so that a corrupt or malicious header claiming a
+// huge matrix makes the input end early instead of exhausting memory.
+func readElements(r io.Reader, count int, dtype DType, order binary.ByteOrder) ([]T, error) {
+	size := dtype.size()
+	c := count
+	if c > loadChunk {
+		c = loadChunk
+	}
+	data := make([]T, 0, c)
+	buf := make([]byte, c*size)
+	for len(data) < count {
+		k := count - len(data)
+		if k > loadChunk {
+			k = loadChunk
+		}
+		b := buf[:k*size]
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		for ; len(b) > 0; b = b[size:] {
+			if dtype == Float32 {
+				data = append(data, T(math.Float32frombits(order.Uint32(b))))
+			} else {
+				data = append(data, T(math.Float64frombits(order.Uint64(b))))
+			}
+		}
+	}
+	return data, nil
+}
+
+// unexpectedEOF converts io.EOF into io.ErrUnexpectedEOF: once the
+// magic has been read, the input must not end.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// SaveFile writes a in the binary matrix format to the named file.
+func (a *Matrix) SaveFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := a.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadFile reads a matrix in the binary matrix format from the named file.
+func LoadFile(filename string) (*Matrix, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
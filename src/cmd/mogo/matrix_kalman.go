@@ -0,0 +1,71 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A Kalman is a linear Kalman filter for the state-space model
+//
+//	x[t+1] = F*x[t] + w,  w ~ N(0, Q)
+//	z[t]   = H*x[t] + v,  v ~ N(0, R)
+//
+// with an n-element state x and m-element measurements z. X and P are
+// the current state estimate and its covariance; Predict and Update
+// advance them. All fields must be set before use: F and Q are n×n,
+// H is m×n, and R is m×m.
+type Kalman struct {
+	X    *Vector // state estimate
+	P    *Matrix // covariance of the estimate
+	F, Q *Matrix // state transition and process noise covariance
+	H, R *Matrix // measurement matrix and measurement noise covariance
+}
+
+// Predict advances the estimate by one time step without a
+// measurement: X = F*X and P = F*P*Fᵀ + Q.
+func (k *Kalman) Predict() {
+	k.X = k.F.MulVec(k.X)
+	p := k.F * k.P * k.F.Transpose()
+	AddTo(p, p, k.Q)
+	k.P = symmetrize(p)
+}
+
+// Update incorporates the measurement z into the estimate. The gain
+// K = P*Hᵀ*S⁻¹, where S = H*P*Hᵀ + R is the innovation covariance, is
+// obtained from the Cholesky factorization of S rather than from its
+// inverse. If S is not positive definite, the result is ErrNotSPD and
+// the estimate is unchanged.
+func (k *Kalman) Update(z *Vector) error {
+	hp := k.H * k.P // m×n; P*Hᵀ = (H*P)ᵀ since P is symmetric
+	s := hp * k.H.Transpose()
+	AddTo(s, s, k.R)
+	f, err := s.Cholesky()
+	if err != nil {
+		return err
+	}
+	// innovation y = z - H*X
+	y := z.Sub(k.H.MulVec(k.X))
+	kt := f.SolveMat(hp) // Kᵀ = S⁻¹*H*P
+	k.X = k.X.Add(kt.MulVecTrans(y))
+	// P = P - K*S*Kᵀ = P - (H*P)ᵀ*Kᵀ
+	p := hp.Transpose() * kt
+	SubTo(p, k.P, p)
+	k.P = symmetrize(p)
+	return nil
+}
+
+// symmetrize replaces the square matrix a by (a + aᵀ)/2 in place,
+// removing the asymmetry that rounding errors introduce into
+// covariance updates, and returns a.
+func symmetrize(a *Matrix) *Matrix {
+	n, _ := a.Len()
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			t := (a[i, j] + a[j, i]) / 2
+			a[i, j] = t
+			a[j, i] = t
+		}
+	}
+	return a
+}
@@ -0,0 +1,54 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// Inner loops of the vector operations for contiguous (unit stride)
+// operands.
+//
+// These are written in Go rather than assembly: mogo rewrites and
+// builds a set of .go files as a single program, which cannot carry
+// .s files; a separate package with assembly would have to be
+// installed next to the example and duplicated for every element type
+// T. The compiler has no SIMD intrinsics and does not vectorize loops
+// either. Instead the loops are unrolled by four, with
+// independent accumulators in dotUnitary so that the additions do not
+// wait for each other, and the slices are resliced to a common length
+// so that the compiler drops most of the bounds checks.
+
+// dotUnitary returns the dot product of x and y, which have the same
+// length. The sum is accumulated in four interleaved partial sums, so
+// it may differ from a sequential sum by rounding.
+func dotUnitary(x, y []T) T {
+	y = y[:len(x)]
+	var t0, t1, t2, t3 T
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		t0 += x[i] * y[i]
+		t1 += x[i+1] * y[i+1]
+		t2 += x[i+2] * y[i+2]
+		t3 += x[i+3] * y[i+3]
+	}
+	for ; i < len(x); i++ {
+		t0 += x[i] * y[i]
+	}
+	return (t0 + t1) + (t2 + t3)
+}
+
+// axpyUnitary sets y[i] += alpha*x[i] for x and y of the same length.
+func axpyUnitary(alpha T, x, y []T) {
+	y = y[:len(x)]
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		y[i] += alpha * x[i]
+		y[i+1] += alpha * x[i+1]
+		y[i+2] += alpha * x[i+2]
+		y[i+3] += alpha * x[i+3]
+	}
+	for ; i < len(x); i++ {
+		y[i] += alpha * x[i]
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// A Labeled is a matrix with named rows and columns, such as a table
+// read by ReadLabeledCSV, that can be indexed by name. Either set of
+// names may be absent; rows and columns without names can still be
+// accessed by index through Matrix.
+type Labeled struct {
+	a          *Matrix
+	rows, cols []string
+	rowIndex   map[string]int
+	colIndex   map[string]int
+}
+
+// NewLabeled returns a labeled matrix with the elements of a (shared,
+// not copied) and the given row and column names. A nil slice of
+// names leaves the rows, or columns, unnamed; otherwise there must be
+// one distinct name per row, or column.
+func NewLabeled(a *Matrix, rows, cols []string) *Labeled {
+	return &Labeled{a, rows, cols, labelIndex(rows, a.len[0]), labelIndex(cols, a.len[1])}
+}
+
+func labelIndex(names []string, n int) map[string]int {
+	if names == nil {
+		return nil
+	}
+	if len(names) != n {
+		panic("incompatible number of labels")
+	}
+	index := make(map[string]int, n)
+	for i, name := range names {
+		if _, dup := index[name]; dup {
+			panic("duplicate label " + name)
+		}
+		index[name] = i
+	}
+	return index
+}
+
+// ReadLabeledCSV reads numeric CSV data like ReadCSV, taking the column
+// names from the header record.
+func ReadLabeledCSV(r io.Reader, opts *CSVOptions) (*Labeled, error) {
+	o := CSVOptions{Header: true}
+	if opts != nil {
+		o = *opts
+		o.Header = true
+	}
+	a, header, err := ReadCSV(r, &o)
+	if err != nil {
+		return nil, err
+	}
+	return NewLabeled(a, nil, header), nil
+}
+
+// Matrix returns the underlying matrix, sharing l's storage.
+func (l *Labeled) Matrix() *Matrix { return l.a }
+
+// RowNames and ColNames return the row and column names, or nil.
+func (l *Labeled) RowNames() []string { return l.rows }
+func (l *Labeled) ColNames() []string { return l.cols }
+
+func (l *Labeled) rowIdx(name string) int {
+	i, ok := l.rowIndex[name]
+	if !ok {
+		panic("unknown row " + name)
+	}
+	return i
+}
+
+func (l *Labeled) colIdx(name string) int {
+	j, ok := l.colIndex[name]
+	if !ok {
+		panic("unknown column " + name)
+	}
+	return j
+}
+
+// At returns the element in the named row and column.
+func (l *Labeled) At(row, col string) T { return l.a[l.rowIdx(row), l.colIdx(col)] }
+
+// SetAt sets the element in the named row and column to x.
+func (l *Labeled) SetAt(row, col string, x T) { l.a[l.rowIdx(row), l.colIdx(col)] = x }
+
+// Row and Col return the named row and column as views.
+func (l *Labeled) Row(name string) *Vector { return l.a.Row(l.rowIdx(name)) }
+func (l *Labeled) Col(name string) *Vector { return l.a.Col(l.colIdx(name)) }
+
+// Select returns a copy of the named rows and columns of l, in the
+// given order. A nil rows or cols selects all of them.
+func (l *Labeled) Select(rows, cols []string) *Labeled {
+	ri := selectIdx(rows, l.a.len[0], l.rowIdx)
+	ci := selectIdx(cols, l.a.len[1], l.colIdx)
+	a := NewMatrix(len(ri), len(ci))
+	for i, r := range ri {
+		for j, c := range ci {
+			a[i, j] = l.a[r, c]
+		}
+	}
+	if rows == nil {
+		rows = l.rows
+	}
+	if cols == nil {
+		cols = l.cols
+	}
+	return NewLabeled(a, rows, cols)
+}
+
+func selectIdx(names []string, n int, idx func(string) int) []int {
+	var s []int
+	if names == nil {
+		s = make([]int, n)
+		for i := range s {
+			s[i] = i
+		}
+		return s
+	}
+	for _, name := range names {
+		s = append(s, idx(name))
+	}
+	return s
+}
+
+// Fprint writes l as a table to w, with a header line of column
+// names and each row preceded by its name, all aligned in columns.
+// Only the Format of opts is used; "" means "%g".
+func (l *Labeled) Fprint(w io.Writer, opts *PrintOptions) error {
+	format := "%g"
+	if opts != nil && opts.Format != "" {
+		format = opts.Format
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	n, m := l.a.Len()
+	if l.cols != nil {
+		if l.rows != nil {
+			fmt.Fprint(tw, "\t")
+		}
+		for _, c := range l.cols {
+			fmt.Fprintf(tw, "%s\t", c)
+		}
+		fmt.Fprintln(tw)
+	}
+	for i := 0; i < n; i++ {
+		if l.rows != nil {
+			fmt.Fprintf(tw, "%s\t", l.rows[i])
+		}
+		for j := 0; j < m; j++ {
+			fmt.Fprintf(tw, format+"\t", l.a[i, j])
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
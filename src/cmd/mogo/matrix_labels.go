@@ -0,0 +1,44 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// OneHot returns the len(labels)×classes one-hot encoding of labels:
+// row i has a 1 in column labels[i] and zeros elsewhere. The labels
+// must lie in [0, classes).
+func OneHot(labels []int, classes int) *Matrix {
+	a := NewMatrix(len(labels), classes)
+	for i, l := range labels {
+		if uint(l) >= uint(classes) {
+			panic("label out of range")
+		}
+		a[i, l] = 1
+	}
+	return a
+}
+
+// ArgmaxLabels returns, for each row of a, the column index of its
+// largest element (the first one, if there are several), inverting
+// OneHot and turning rows of class scores or probabilities into
+// predicted labels. NaN elements are ignored; a row consisting only
+// of NaNs yields label 0.
+func (a *Matrix) ArgmaxLabels() []int {
+	n, m := a.Len()
+	if m == 0 {
+		panic("invalid length")
+	}
+	labels := make([]int, n)
+	for i := 0; i < n; i++ {
+		best := 0
+		for j := 1; j < m; j++ {
+			if x := a[i, j]; x == x && (x > a[i, best] || a[i, best] != a[i, best]) {
+				best = j
+			}
+		}
+		labels[i] = best
+	}
+	return labels
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A SolveMethod selects the kind of algorithm used by LinSolve.
+type SolveMethod int
+
+const (
+	MethodAuto      SolveMethod = iota // chosen by size and structure
+	MethodDirect                       // dense factorization
+	MethodIterative                    // Krylov subspace method
+)
+
+// LinSolveOptions controls LinSolve.
+// A nil *LinSolveOptions is equivalent to the zero value.
+type LinSolveOptions struct {
+	Method SolveMethod
+	// Tol and MaxIter control iterative methods, as for CG.
+	Tol     T
+	MaxIter int
+}
+
+// denseSolveLimit is the largest size of a sparse matrix that
+// LinSolve solves with a dense factorization by default. Below it,
+// the O(n³) factorization is cheap enough and more robust than an
+// iterative method.
+const denseSolveLimit = 500
+
+// LinSolve solves the linear system a*x = b, or the least-squares
+// problem of minimizing ‖a*x - b‖₂ if a is not square. The matrix a
+// may be a dense *Matrix, any Sparse matrix, or any LinearOperator;
+// LinSolve picks the algorithm:
+//
+//   - Dense matrices are solved directly, square ones with SmartSolve
+//     and others with LeastSquares; packed matrices (SymMatrix,
+//     TriMatrix, BandMatrix) with their Solve method.
+//   - Sparse matrices up to denseSolveLimit rows and columns are
+//     converted to dense and solved directly, unless opts.Method is
+//     MethodIterative; larger ones are solved iteratively, unless
+//     opts.Method is MethodDirect.
+//   - Iteratively, symmetric sparse matrices are solved with CG and
+//     an IC(0) preconditioner if the factorization succeeds (as it
+//     does for M-matrices like Laplacians), and all other sparse
+//     matrices with LSMR.
+//   - A LinearOperator that is not a Sparse matrix is solved with
+//     LSMR if it is a TransposeOperator, and with (unpreconditioned)
+//     CG otherwise, which requires it to be symmetric positive
+//     definite.
+//
+// The errors are those of the chosen algorithm.
+func LinSolve(a interface{}, b *Vector, opts *LinSolveOptions) (*Vector, error) {
+	if opts == nil {
+		opts = new(LinSolveOptions)
+	}
+	switch a := a.(type) {
+	case *Matrix:
+		if n, m := a.Len(); n != m {
+			return LeastSquares(a, b)
+		}
+		x, _, err := a.SmartSolve(b)
+		return x, err
+	case *SymMatrix:
+		return a.Solve(b)
+	case *TriMatrix:
+		return a.Solve(b)
+	case *BandMatrix:
+		return a.Solve(b)
+	case Sparse:
+		n, m := a.Len()
+		direct := n <= denseSolveLimit && m <= denseSolveLimit
+		switch opts.Method {
+		case MethodDirect:
+			direct = true
+		case MethodIterative:
+			direct = false
+		}
+		s := toCSR(a)
+		if direct {
+			return LinSolve(s.Dense(), b, opts)
+		}
+		if _, ok := a.(*SymCSR); ok || s.isSymmetric() {
+			if ic, err := NewIncompleteCholesky(s); err == nil {
+				x, _, err := CG(s, b, &CGOptions{Tol: opts.Tol, MaxIter: opts.MaxIter, Precond: ic})
+				return x, err
+			}
+		}
+		x, _, err := LSMR(s, b, &LSQROptions{Tol: opts.Tol, MaxIter: opts.MaxIter})
+		return x, err
+	case TransposeOperator:
+		x, _, err := LSMR(a, b, &LSQROptions{Tol: opts.Tol, MaxIter: opts.MaxIter})
+		return x, err
+	case LinearOperator:
+		x, _, err := CG(a, b, &CGOptions{Tol: opts.Tol, MaxIter: opts.MaxIter})
+		return x, err
+	}
+	panic("unsupported matrix type")
+}
+
+// toCSR returns the sparse matrix s in CSR format.
+func toCSR(s Sparse) *CSR {
+	if c, ok := s.(*CSR); ok {
+		return c
+	}
+	n, m := s.Len()
+	t := NewCOO(n, m)
+	s.NonZeros(func(i, j int, v T) bool {
+		t.Append(i, j, v)
+		return true
+	})
+	return t.CSR()
+}
+
+// isSymmetric reports whether s is (exactly) symmetric.
+func (s *CSR) isSymmetric() bool {
+	n, m := s.Len()
+	if n != m {
+		return false
+	}
+	t := s.Transpose()
+	if len(t.ind) != len(s.ind) {
+		return false
+	}
+	for i := range s.ptr {
+		if s.ptr[i] != t.ptr[i] {
+			return false
+		}
+	}
+	for k := range s.ind {
+		if s.ind[k] != t.ind[k] || s.val[k] != t.val[k] {
+			return false
+		}
+	}
+	return true
+}
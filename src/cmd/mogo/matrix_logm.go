@@ -0,0 +1,186 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// ErrNoPrincipalLog is returned by Logm for matrices with an
+// eigenvalue on the closed negative real axis, which have no
+// (real) principal logarithm.
+var ErrNoPrincipalLog = errors.New("matrix has eigenvalues on the closed negative real axis")
+
+// Logm returns the principal logarithm of the square matrix a: the
+// unique real matrix l with exp(l) = a whose eigenvalues have
+// imaginary parts in (-π, π). It uses the inverse scaling and squaring
+// method on the Schur form (see Higham, "Functions of Matrices",
+// Section 11.5): a = q*t*qᴴ with upper triangular t, square roots of t
+// are taken until t^(1/2^s) is close to the identity, log(t^(1/2^s))
+// is approximated by a Padé approximant, and log(a) = 2^s * q *
+// log(t^(1/2^s)) * qᴴ. If a has an eigenvalue on the closed negative
+// real axis (including 0), the result is ErrNoPrincipalLog.
+func (a *Matrix) Logm() (*Matrix, error) {
+	MustBeSquare("Logm", a)
+	n, _ := a.Len()
+	b, d := a.Balance()
+	t, z, err := schur(b, true)
+	if err != nil {
+		return nil, err
+	}
+	u, r := complexSchur(t)
+	for i := 0; i < n; i++ {
+		if lambda := r[i, i]; imag(lambda) == 0 && real(lambda) <= 0 {
+			return nil, ErrNoPrincipalLog
+		}
+	}
+
+	// take square roots until ‖r - I‖₁ <= 0.25, where the degree 8
+	// Padé approximant is accurate to working precision
+	const maxRoots = 64
+	s := 0
+	for ; s < maxRoots && identityDist(r) > 0.25; s++ {
+		r = sqrtTriangular(r)
+	}
+	l := logPade(r)
+	scale := T(math.Ldexp(1, s))
+
+	// log(a) = D * z * u * 2^s*l * uᴴ * zᵀ * D⁻¹
+	c := (u * l * u.ConjTranspose()).Real()
+	c = z * c * z.Transpose()
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			c[i, j] *= scale * d[i] / d[j]
+		}
+	}
+	return c, nil
+}
+
+// complexSchur converts the real Schur form t (as computed by schur,
+// with 2×2 diagonal blocks only for complex conjugate pairs of
+// eigenvalues) into the complex Schur form t = u*r*uᴴ, where u is
+// unitary and r is upper triangular.
+func complexSchur(t *Matrix) (u, r *CMatrix) {
+	n, _ := t.Len()
+	r = t.Complex()
+	u = Identity(n).Complex()
+	for k := 0; k < n-1; k++ {
+		if r[k+1, k] == 0 {
+			continue
+		}
+		// eigenvector (b, λ-a) of the block [a b; c d]
+		a, b := r[k, k], r[k, k+1]
+		lambda := schurEigenvalues(t.Slice(k, k+2, k, k+2))[0]
+		v0, v1 := b, lambda-a
+		h := complex(math.Hypot(cmplx.Abs(v0), cmplx.Abs(v1)), 0)
+		v0, v1 = v0/h, v1/h
+		// G = [v0 -conj(v1); v1 conj(v0)]: rows k, k+1 by Gᴴ, columns by G
+		for j := k; j < n; j++ {
+			x, y := r[k, j], r[k+1, j]
+			r[k, j] = conj(v0)*x + conj(v1)*y
+			r[k+1, j] = -v1*x + v0*y
+		}
+		for _, m := range [...]*CMatrix{r, u} {
+			rows := k + 2
+			if m == u {
+				rows = n
+			}
+			for i := 0; i < rows; i++ {
+				x, y := m[i, k], m[i, k+1]
+				m[i, k] = x*v0 + y*v1
+				m[i, k+1] = -x*conj(v1) + y*conj(v0)
+			}
+		}
+		r[k+1, k] = 0
+		k++ // skip the second row of the block
+	}
+	return u, r
+}
+
+// identityDist returns ‖r - I‖₁.
+func identityDist(r *CMatrix) T {
+	n, _ := r.Len()
+	var max T
+	for j := 0; j < n; j++ {
+		var s T
+		for i := 0; i < n; i++ {
+			x := r[i, j]
+			if i == j {
+				x--
+			}
+			s += T(cmplx.Abs(x))
+		}
+		if s > max {
+			max = s
+		}
+	}
+	return max
+}
+
+// sqrtTriangular returns the principal square root of the upper
+// triangular matrix r, computed column by column from r = x*x (the
+// Björck-Hammarling recurrence).
+func sqrtTriangular(r *CMatrix) *CMatrix {
+	n, _ := r.Len()
+	x := NewCMatrix(n, n)
+	for j := 0; j < n; j++ {
+		x[j, j] = cmplx.Sqrt(r[j, j])
+		for i := j - 1; i >= 0; i-- {
+			s := r[i, j]
+			for k := i + 1; k < j; k++ {
+				s -= x[i, k] * x[k, j]
+			}
+			x[i, j] = s / (x[i, i] + x[j, j])
+		}
+	}
+	return x
+}
+
+// Nodes and weights of the 8-point Gauss-Legendre rule on [-1, 1].
+var gaussLegendre8 = [...][2]float64{
+	{-0.9602898564975363, 0.1012285362903763},
+	{-0.7966664774136267, 0.2223810344533745},
+	{-0.5255324099163290, 0.3137066458778873},
+	{-0.1834346424956498, 0.3626837833783620},
+	{0.1834346424956498, 0.3626837833783620},
+	{0.5255324099163290, 0.3137066458778873},
+	{0.7966664774136267, 0.2223810344533745},
+	{0.9602898564975363, 0.1012285362903763},
+}
+
+// logPade returns the degree 8 Padé approximant of log(r) for the
+// upper triangular matrix r close to the identity, in partial fraction
+// form: with x = r - I, log(I + x) = ∫₀¹ x*(I + τ*x)⁻¹ dτ, evaluated
+// by Gauss-Legendre quadrature.
+func logPade(r *CMatrix) *CMatrix {
+	n, _ := r.Len()
+	x := r.Copy()
+	for i := 0; i < n; i++ {
+		x[i, i]--
+	}
+	l := NewCMatrix(n, n)
+	for _, nw := range gaussLegendre8 {
+		tau, w := complex((nw[0]+1)/2, 0), complex(nw[1]/2, 0)
+		// solve (I + τ*x) * y = x by back substitution, column by column
+		for j := 0; j < n; j++ {
+			y := make([]complex128, j+1)
+			for i := j; i >= 0; i-- {
+				s := x[i, j]
+				for k := i + 1; k <= j; k++ {
+					s -= tau * x[i, k] * y[k]
+				}
+				y[i] = s / (1 + tau*x[i, i])
+			}
+			for i, yi := range y {
+				l[i, j] += w * yi
+			}
+		}
+	}
+	return l
+}
@@ -0,0 +1,163 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "errors"
+
+// Errors returned by SolveLP.
+var (
+	ErrInfeasible = errors.New("linear program is infeasible")
+	ErrUnbounded  = errors.New("linear program is unbounded")
+)
+
+// lpTol is the tolerance used by the simplex method to decide
+// whether a value is zero.
+const lpTol = 1e-9
+
+// SolveLP solves the linear program in standard form
+//
+//	minimize cᵀx subject to a*x = b, x >= 0
+//
+// using the two-phase dense simplex method with Bland's rule, which
+// prevents cycling. It returns the optimal x and the optimal value
+// cᵀx, or ErrInfeasible if the constraints cannot be satisfied, or
+// ErrUnbounded if the objective is unbounded below.
+func SolveLP(c *Vector, a *Matrix, b *Vector) (x *Vector, opt T, err error) {
+	m, n := a.Len()
+	if c.Len() != n || b.Len() != m {
+		panic("incompatible matrix and vector sizes")
+	}
+
+	// Tableau with one row per constraint plus the objective row, and
+	// one column per variable, one per artificial variable, plus the
+	// right-hand side. Rows are negated as needed to make b >= 0.
+	rhs := n + m
+	t := NewMatrix(m+1, rhs+1)
+	basis := make([]int, m)
+	for i := 0; i < m; i++ {
+		s := T(1)
+		if b[i] < 0 {
+			s = -1
+		}
+		for j := 0; j < n; j++ {
+			t[i, j] = s * a[i, j]
+		}
+		t[i, n+i] = 1
+		t[i, rhs] = s * b[i]
+		basis[i] = n + i
+	}
+
+	// Phase 1: minimize the sum of the artificial variables.
+	for i := 0; i < m; i++ {
+		for j := 0; j <= rhs; j++ {
+			if j < n || j == rhs {
+				t[m, j] -= t[i, j]
+			}
+		}
+	}
+	if simplex(t, basis, rhs) != nil {
+		panic("unreachable") // the phase 1 objective is bounded below by 0
+	}
+	if -t[m, rhs] > lpTol {
+		return nil, 0, ErrInfeasible
+	}
+	// Drive remaining (zero) artificial variables out of the basis;
+	// if that's impossible, the constraint is redundant.
+	for i := 0; i < m; i++ {
+		if basis[i] < n {
+			continue
+		}
+		for j := 0; j < n; j++ {
+			if abs(t[i, j]) > lpTol {
+				pivot(t, basis, i, j)
+				break
+			}
+		}
+	}
+
+	// Phase 2: minimize cᵀx, never letting artificial variables enter.
+	for j := 0; j <= rhs; j++ {
+		t[m, j] = 0
+		if j < n {
+			t[m, j] = c[j]
+		}
+	}
+	for i, k := range basis {
+		if k < n && c[k] != 0 {
+			ck := c[k]
+			for j := 0; j <= rhs; j++ {
+				t[m, j] -= ck * t[i, j]
+			}
+		}
+	}
+	if err := simplex(t, basis, n); err != nil {
+		return nil, 0, err
+	}
+
+	x = NewVector(n)
+	for i, k := range basis {
+		if k < n {
+			x[k] = t[i, rhs]
+		}
+	}
+	return x, c * x, nil
+}
+
+// simplex runs simplex iterations on the tableau t with the given
+// basis, considering only the first ncols columns as entering
+// candidates, until the reduced costs in the last row are nonnegative.
+// Bland's rule chooses the entering and leaving variables.
+func simplex(t *Matrix, basis []int, ncols int) error {
+	m := len(basis)
+	_, rhs := t.Len()
+	rhs--
+	for {
+		// entering variable: first column with negative reduced cost
+		e := -1
+		for j := 0; j < ncols; j++ {
+			if t[m, j] < -lpTol {
+				e = j
+				break
+			}
+		}
+		if e < 0 {
+			return nil // optimal
+		}
+		// leaving variable: minimum ratio, ties broken by smallest index
+		l := -1
+		var best T
+		for i := 0; i < m; i++ {
+			if t[i, e] > lpTol {
+				r := t[i, rhs] / t[i, e]
+				if l < 0 || r < best-lpTol || r <= best+lpTol && basis[i] < basis[l] {
+					l, best = i, r
+				}
+			}
+		}
+		if l < 0 {
+			return ErrUnbounded
+		}
+		pivot(t, basis, l, e)
+	}
+}
+
+// pivot performs a simplex pivot on t[l, e], making variable e basic in row l.
+func pivot(t *Matrix, basis []int, l, e int) {
+	n, m := t.Len()
+	p := t[l, e]
+	for j := 0; j < m; j++ {
+		t[l, j] /= p
+	}
+	for i := 0; i < n; i++ {
+		if f := t[i, e]; i != l && f != 0 {
+			for j := 0; j < m; j++ {
+				t[i, j] -= f * t[l, j]
+			}
+		}
+	}
+	basis[l] = e
+}
@@ -0,0 +1,287 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "math"
+
+// A TransposeOperator is a linear operator that can also apply its
+// transpose, as needed by least-squares solvers for non-square
+// systems.
+type TransposeOperator interface {
+	LinearOperator
+	MulVecTrans(x *Vector) *Vector
+}
+
+// LSQROptions controls LSQR and LSMR.
+// A nil *LSQROptions is equivalent to the zero value.
+type LSQROptions struct {
+	// Tol is the relative accuracy of the data and the convergence
+	// threshold of both stopping tests; 0 means 1e-10. The iteration
+	// stops if ‖r‖ <= Tol*(‖b‖ + ‖A‖*‖x‖), for compatible systems, or
+	// if ‖Aᵀ*r‖ <= Tol*‖A‖*‖r‖, for least-squares problems, where
+	// r = b - A*x and ‖A‖ is estimated from the iteration.
+	Tol T
+	// Damp is the regularization parameter λ: if nonzero, the solvers
+	// minimize ‖A*x - b‖₂² + λ²‖x‖₂² (ridge regression).
+	Damp T
+	// MaxIter is the maximum number of iterations; 0 means 10*m for
+	// m unknowns.
+	MaxIter int
+	// Checkpointer, if not nil, is called after every iteration with
+	// the state of the method: the iterate x, the other vectors of the
+	// recurrences, and a column of their scalars, as documented by
+	// LSQR and LSMR.
+	Checkpointer Checkpointer
+	// Resume, if not nil, is a checkpoint of a previous run of the
+	// same method for the same problem to continue from, instead of
+	// starting from x = 0.
+	Resume *Checkpoint
+}
+
+func (opts *LSQROptions) defaults(m int) (tol T, maxIter int) {
+	tol, maxIter = opts.Tol, opts.MaxIter
+	if tol <= 0 {
+		tol = 1e-10
+	}
+	if maxIter <= 0 {
+		maxIter = 10 * m
+	}
+	return
+}
+
+// LSQR returns the solution x minimizing ‖a*x - b‖₂ (or the damped
+// objective; see LSQROptions), starting from x = 0, with the method of
+// Paige and Saunders ("LSQR: An algorithm for sparse linear equations
+// and sparse least squares", 1982). It is equivalent to CG on the
+// normal equations aᵀ*a*x = aᵀ*b, but numerically more reliable, and
+// needs only products of a and aᵀ with vectors. It also returns the
+// number of iterations. If the method does not converge within the
+// iteration limit, the result is the last iterate along with
+// ErrNoConvergence.
+//
+// The state of its checkpoints is x, the bidiagonalization vectors u
+// and v, the search direction w, and the 5×1 matrix of the scalars
+// ‖b‖, α, φ̄, ρ̄, and the estimate of ‖A‖².
+func LSQR(a TransposeOperator, b *Vector, opts *LSQROptions) (x *Vector, iters int, err error) {
+	if opts == nil {
+		opts = new(LSQROptions)
+	}
+	n, m := a.Len()
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	tol, maxIter := opts.defaults(m)
+	damp := opts.Damp
+
+	var u, v, w *Vector
+	var bnorm, alpha, phibar, rhobar, anorm2 T
+	if c := opts.Resume; c != nil {
+		s := checkpointState(c, 5)
+		x, u, v, w = checkpointVector(s[0], m), checkpointVector(s[1], n), checkpointVector(s[2], m), checkpointVector(s[3], m)
+		checkpointScalars(s[4], &bnorm, &alpha, &phibar, &rhobar, &anorm2)
+		iters = c.Iter
+	} else {
+		// Golub-Kahan bidiagonalization: beta*u = b, alpha*v = aᵀ*u
+		x = NewVector(m)
+		u = b.Copy()
+		bnorm = normalize(u)
+		if bnorm == 0 {
+			return x, 0, nil
+		}
+		v = a.MulVecTrans(u)
+		alpha = normalize(v)
+		if alpha == 0 {
+			return x, 0, nil // aᵀ*b = 0: x = 0 is optimal
+		}
+		w = v.Copy()
+		phibar, rhobar = bnorm, alpha
+		anorm2 = alpha * alpha
+	}
+
+	for iters < maxIter {
+		iters++
+		// continue the bidiagonalization
+		u = a.MulVec(v).AXPY(-alpha, u)
+		beta := normalize(u)
+		v = a.MulVecTrans(u).AXPY(-beta, v)
+		alpha = normalize(v)
+		anorm2 += alpha*alpha + beta*beta + damp*damp
+
+		// eliminate the damping parameter, then the subdiagonal beta
+		// with plane rotations
+		rhobar1 := T(math.Hypot(float64(rhobar), float64(damp)))
+		cs1 := rhobar / rhobar1
+		phibar *= cs1
+		rho := T(math.Hypot(float64(rhobar1), float64(beta)))
+		c, s := rhobar1/rho, beta/rho
+		theta := s * alpha
+		rhobar = -c * alpha
+		phi := c * phibar
+		phibar *= s
+
+		// update x and the search direction w
+		for i := 0; i < m; i++ {
+			x[i] += phi / rho * w[i]
+			w[i] = v[i] - theta/rho*w[i]
+		}
+		if cp := opts.Checkpointer; cp != nil {
+			state := []*Matrix{colMatrix(x), colMatrix(u), colMatrix(v), colMatrix(w), scalarState(bnorm, alpha, phibar, rhobar, anorm2)}
+			if err := cp.Checkpoint(&Checkpoint{iters, state}); err != nil {
+				return x, iters, err
+			}
+		}
+
+		// |phibar| = ‖r‖, |phibar*alpha*c| = ‖Aᵀ*r‖
+		anorm := sqrt(anorm2)
+		if lsqrConverged(tol, bnorm, anorm, sqrt(x*x), abs(phibar), abs(phibar*alpha*c)) {
+			return x, iters, nil
+		}
+	}
+	return x, iters, ErrNoConvergence
+}
+
+// LSMR returns the solution x minimizing ‖a*x - b‖₂ (or the damped
+// objective; see LSQROptions), starting from x = 0, with the method of
+// Fong and Saunders ("LSMR: An iterative algorithm for sparse
+// least-squares problems", 2011). It is equivalent to MINRES on the
+// normal equations: unlike with LSQR, the norm ‖Aᵀ*r‖ decreases
+// monotonically, so LSMR can be stopped early more safely. Otherwise
+// it behaves like LSQR.
+//
+// The state of its checkpoints is x, the bidiagonalization vectors u
+// and v, the vectors h and h̄ of the paper, and the 16×1 matrix of the
+// scalars ‖b‖, α, ᾱ, ζ̄, ρ, ρ̄, c̄, s̄, β̈, β̇, ρ̇, τ̃, θ̃, ζ, the sum of
+// the β̌², and the estimate of ‖A‖².
+func LSMR(a TransposeOperator, b *Vector, opts *LSQROptions) (x *Vector, iters int, err error) {
+	if opts == nil {
+		opts = new(LSQROptions)
+	}
+	n, m := a.Len()
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	tol, maxIter := opts.defaults(m)
+	damp := opts.Damp
+
+	// the variable names follow the paper; betadd through d are for
+	// estimating ‖r‖
+	var u, v, h, hbar *Vector
+	var bnorm, alpha, alphabar, zetabar, rho, rhobar, cbar, sbar T
+	var betadd, betad, rhodold, tautildeold, thetatilde, zeta, d, anorm2 T
+	if c := opts.Resume; c != nil {
+		s := checkpointState(c, 6)
+		x, u, v = checkpointVector(s[0], m), checkpointVector(s[1], n), checkpointVector(s[2], m)
+		h, hbar = checkpointVector(s[3], m), checkpointVector(s[4], m)
+		checkpointScalars(s[5], &bnorm, &alpha, &alphabar, &zetabar, &rho, &rhobar, &cbar, &sbar,
+			&betadd, &betad, &rhodold, &tautildeold, &thetatilde, &zeta, &d, &anorm2)
+		iters = c.Iter
+	} else {
+		x = NewVector(m)
+		u = b.Copy()
+		bnorm = normalize(u)
+		if bnorm == 0 {
+			return x, 0, nil
+		}
+		v = a.MulVecTrans(u)
+		alpha = normalize(v)
+		if alpha == 0 {
+			return x, 0, nil
+		}
+		zetabar, alphabar = alpha*bnorm, alpha
+		rho, rhobar, cbar, sbar = 1, 1, 1, 0
+		h, hbar = v.Copy(), NewVector(m)
+		betadd, rhodold = bnorm, 1
+		anorm2 = alpha * alpha
+	}
+
+	for iters < maxIter {
+		iters++
+		u = a.MulVec(v).AXPY(-alpha, u)
+		beta := normalize(u)
+		v = a.MulVecTrans(u).AXPY(-beta, v)
+		alpha = normalize(v)
+
+		// construct the rotation eliminating damp
+		chat, shat, alphahat := symOrtho(alphabar, damp)
+
+		// the rotations P and Pbar
+		rhoold := rho
+		var c, s T
+		c, s, rho = symOrtho(alphahat, beta)
+		thetanew := s * alpha
+		alphabar = c * alpha
+		rhobarold, zetaold := rhobar, zeta
+		thetabar := sbar * rho
+		cbar, sbar, rhobar = symOrtho(cbar*rho, thetanew)
+		zeta = cbar * zetabar
+		zetabar = -sbar * zetabar
+
+		// update h, hbar, and x
+		for i := 0; i < m; i++ {
+			hbar[i] = h[i] - thetabar*rho/(rhoold*rhobarold)*hbar[i]
+			x[i] += zeta / (rho * rhobar) * hbar[i]
+			h[i] = v[i] - thetanew/rho*h[i]
+		}
+
+		// estimate ‖r‖
+		betaacute, betacheck := chat*betadd, -shat*betadd
+		betahat := c * betaacute
+		betadd = -s * betaacute
+		thetatildeold := thetatilde
+		ctildeold, stildeold, rhotildeold := symOrtho(rhodold, thetabar)
+		thetatilde = stildeold * rhobar
+		rhodold = ctildeold * rhobar
+		betad = -stildeold*betad + ctildeold*betahat
+		tautildeold = (zetaold - thetatildeold*tautildeold) / rhotildeold
+		taud := (zeta - thetatilde*tautildeold) / rhodold
+		d += betacheck * betacheck
+		rnorm := sqrt(d + (betad-taud)*(betad-taud) + betadd*betadd)
+
+		anorm2 += beta * beta
+		anorm := sqrt(anorm2)
+		anorm2 += alpha*alpha + damp*damp
+		if cp := opts.Checkpointer; cp != nil {
+			state := []*Matrix{colMatrix(x), colMatrix(u), colMatrix(v), colMatrix(h), colMatrix(hbar),
+				scalarState(bnorm, alpha, alphabar, zetabar, rho, rhobar, cbar, sbar,
+					betadd, betad, rhodold, tautildeold, thetatilde, zeta, d, anorm2)}
+			if err := cp.Checkpoint(&Checkpoint{iters, state}); err != nil {
+				return x, iters, err
+			}
+		}
+		if lsqrConverged(tol, bnorm, anorm, sqrt(x*x), rnorm, abs(zetabar)) {
+			return x, iters, nil
+		}
+	}
+	return x, iters, ErrNoConvergence
+}
+
+// lsqrConverged implements the stopping tests of LSQROptions.Tol.
+func lsqrConverged(tol, bnorm, anorm, xnorm, rnorm, arnorm T) bool {
+	return rnorm <= tol*(bnorm+anorm*xnorm) || arnorm <= tol*anorm*rnorm
+}
+
+// symOrtho returns the plane rotation (c, s) and r = hypot(a, b) with
+// c*a + s*b = r and -s*a + c*b = 0.
+func symOrtho(a, b T) (c, s, r T) {
+	r = T(math.Hypot(float64(a), float64(b)))
+	if r == 0 {
+		return 1, 0, 0
+	}
+	return a / r, b / r, r
+}
+
+// normalize scales x to unit length, unless it is 0, and returns its
+// previous norm.
+func normalize(x *Vector) T {
+	norm := x.Norm(2)
+	if norm > 0 {
+		for i := 0; i < x.Len(); i++ {
+			x[i] /= norm
+		}
+	}
+	return norm
+}
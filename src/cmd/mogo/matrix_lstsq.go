@@ -0,0 +1,195 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "errors"
+
+// ErrRankDeficient is returned by least-squares solvers, together with
+// the minimum-norm solution, for matrices without full rank.
+var ErrRankDeficient = errors.New("matrix is rank deficient")
+
+// LeastSquares returns the solution x minimizing ‖a*x - b‖₂. If a has
+// full column rank, this is computed via a QR factorization with
+// column pivoting. Otherwise (including underdetermined systems), the
+// solution is not unique and LeastSquares returns the one with the
+// smallest norm ‖x‖₂, computed via the SVD with singular values
+// <= max(n, m) * ε * σ₁ treated as zero. If a is also not of full row
+// rank, that solution comes with ErrRankDeficient.
+func LeastSquares(a *Matrix, b *Vector) (*Vector, error) {
+	n, _ := a.Len()
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	x, err := LeastSquaresMat(a, colMatrix(b))
+	return x.Col(0), err
+}
+
+// LeastSquaresMat is LeastSquares for all columns of the matrix b.
+func LeastSquaresMat(a, b *Matrix) (*Matrix, error) {
+	n, m := a.Len()
+	bn, k := b.Len()
+	if bn != n {
+		panic("incompatible matrix sizes")
+	}
+	if n >= m {
+		if f := a.QRPivoted(); f.Rank(-1) == m {
+			return f.solveMat(b), nil
+		}
+	}
+
+	// minimum-norm solution x = v * diag(s)⁻¹ * uᵀ * b
+	u, s, v := svd(a)
+	tol := T(n) * epsilon
+	if m > n {
+		tol = T(m) * epsilon
+	}
+	r := 0
+	for r < s.Len() && s[r] > tol*s[0] {
+		r++
+	}
+	c := firstCols(u, r).Transpose() * b
+	for i := 0; i < r; i++ {
+		row := c.Row(i)
+		for j := 0; j < k; j++ {
+			row[j] /= s[i]
+		}
+	}
+	x := firstCols(v, r) * c
+	if r < s.Len() {
+		return x, ErrRankDeficient
+	}
+	return x, nil
+}
+
+// NNLS returns the solution x of the non-negative least-squares problem
+//
+//	minimize ‖a*x - b‖₂ subject to x >= 0
+//
+// using the active set method of Lawson and Hanson ("Solving Least
+// Squares Problems", 1974). The unconstrained subproblems are solved
+// via QR factorization. If the method doesn't converge within 3*m
+// iterations for an n×m matrix a, the result is the current iterate
+// and ErrNoConvergence.
+//
+// The optimality test on the gradient aᵀ*(b - a*x) is relative to
+// ‖a‖₁*‖b‖₂, so it does not depend on the scaling of a and b. The
+// method also stops once the passive set (the variables allowed to be
+// positive) has n variables, or the next variable's column of a is
+// linearly dependent on those of the passive set: the residual is then
+// (numerically) orthogonal to all columns, so x is optimal. For a wide
+// a (n < m), x thus has at most n positive elements; the solution need
+// not be unique.
+func (a *Matrix) NNLS(b *Vector) (*Vector, error) {
+	n, m := a.Len()
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	tol := 10 * epsilon * norm1(a) * b.Norm(2) * T(n+m)
+
+	x := NewVector(m)
+	passive := make([]bool, m) // the passive set P; its complement is the active set
+	npassive := 0
+	w := gradient(a, x, b)
+	for iter := 0; ; iter++ {
+		if npassive == n {
+			return x, nil // the passive columns span the range of a
+		}
+		// move the most promising active variable to P
+		t := -1
+		for j := 0; j < m; j++ {
+			if !passive[j] && w[j] > tol && (t < 0 || w[j] > w[t]) {
+				t = j
+			}
+		}
+		if t < 0 {
+			return x, nil // Kuhn-Tucker conditions satisfied
+		}
+		if iter >= 3*m {
+			return x, ErrNoConvergence
+		}
+		passive[t] = true
+		npassive++
+
+		for {
+			z, ok := lsPassive(a, b, passive)
+			if !ok {
+				return x, nil // the new column is in the span of the others
+			}
+			// if z is feasible, accept it
+			alpha, k := T(2), -1
+			for j := 0; j < m; j++ {
+				if passive[j] && z[j] <= 0 {
+					if s := x[j] / (x[j] - z[j]); s < alpha {
+						alpha, k = s, j
+					}
+				}
+			}
+			if alpha > 1 {
+				x = z
+				break
+			}
+			// otherwise move towards z as far as feasible and
+			// return the variables that dropped to 0 (at least x[k])
+			// to the active set
+			for j := 0; j < m; j++ {
+				if passive[j] {
+					x[j] += alpha * (z[j] - x[j])
+					if x[j] <= 0 || j == k {
+						x[j] = 0
+						passive[j] = false
+						npassive--
+					}
+				}
+			}
+		}
+		w = gradient(a, x, b)
+	}
+}
+
+// gradient returns aᵀ*(b - a*x), the negative gradient of ½‖a*x - b‖₂².
+func gradient(a *Matrix, x, b *Vector) *Vector {
+	r := residual(a, x, b)
+	_, m := a.Len()
+	w := NewVector(m)
+	for j := 0; j < m; j++ {
+		w[j] = a.Col(j) * r
+	}
+	return w
+}
+
+// lsPassive returns the least-squares solution of a*z = b restricted
+// to the columns j of a for which passive[j] is set; the other
+// elements of z are 0. The result reports whether these columns are
+// (numerically) linearly independent; if not, z is nil.
+func lsPassive(a *Matrix, b *Vector, passive []bool) (*Vector, bool) {
+	n, m := a.Len()
+	var cols []int
+	for j, p := range passive {
+		if p {
+			cols = append(cols, j)
+		}
+	}
+	if len(cols) > n {
+		return nil, false
+	}
+	ap := NewMatrix(n, len(cols))
+	for k, j := range cols {
+		for i := 0; i < n; i++ {
+			ap[i, k] = a[i, j]
+		}
+	}
+	f := qrDecompose(ap, true)
+	if f.Rank(-1) < len(cols) {
+		return nil, false
+	}
+	zp := f.solve(b)
+	z := NewVector(m)
+	for k, j := range cols {
+		z[j] = zp[k]
+	}
+	return z, true
+}
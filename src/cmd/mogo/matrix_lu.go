@@ -0,0 +1,262 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrSingular is returned by factorizations and solvers for singular matrices.
+var ErrSingular = errors.New("matrix is singular")
+
+// An LU is the LU factorization with partial pivoting P*A = L*U of a
+// square matrix A, where P is a permutation matrix, L is unit lower
+// triangular, and U is upper triangular.
+type LU struct {
+	lu    *Matrix // L below the diagonal (implicit unit diagonal), U on and above
+	piv   []int   // row i of P*A is row piv[i] of A
+	swaps []int   // P as a sequence of row swaps: k and swaps[k]
+	sign  int     // sign of the permutation P
+	anorm T       // 1-norm of A
+}
+
+// LU computes the LU factorization of the square matrix a.
+// If a is (exactly) singular, the result is ErrSingular.
+func (a *Matrix) LU() (*LU, error) {
+	return a.LUWith(nil)
+}
+
+// LUWith is like LU, but the factorization is stored in memory
+// borrowed from w (see Workspace).
+func (a *Matrix) LUWith(w *Workspace) (*LU, error) {
+	f, singular := luDecompose(a, w)
+	if singular {
+		return nil, ErrSingular
+	}
+	return f, nil
+}
+
+// luDecompose computes the LU factorization of a using Gaussian
+// elimination with partial pivoting, with storage from w (which may be
+// nil). If a is singular, the result has a zero on the diagonal of U,
+// and singular is set.
+func luDecompose(a *Matrix, w *Workspace) (f *LU, singular bool) {
+	MustBeSquare("LU", a)
+	n, _ := a.Len()
+	lu := CopyTo(w.matrix(n, n), a)
+	piv := w.intBuf(n)
+	swaps := w.intBuf(n)
+	for i := range piv {
+		piv[i] = i
+	}
+	sign := 1
+	for k := 0; k < n; k++ {
+		// find pivot
+		p := k
+		for i := k + 1; i < n; i++ {
+			if abs(lu[i, k]) > abs(lu[p, k]) {
+				p = i
+			}
+		}
+		swaps[k] = p
+		if p != k {
+			for j := 0; j < n; j++ {
+				t := lu[p, j]
+				lu[p, j] = lu[k, j]
+				lu[k, j] = t
+			}
+			piv[p], piv[k] = piv[k], piv[p]
+			sign = -sign
+		}
+
+		d := lu[k, k]
+		if d == 0 {
+			singular = true
+			continue
+		}
+		for i := k + 1; i < n; i++ {
+			l := lu[i, k] / d
+			lu[i, k] = l
+			for j := k + 1; j < n; j++ {
+				lu[i, j] -= l * lu[k, j]
+			}
+		}
+	}
+	return &LU{lu, piv, swaps, sign, norm1(a)}, singular
+}
+
+// SolveVec returns the solution x of A*x = b for the factorized
+// matrix A.
+func (f *LU) SolveVec(b *Vector) *Vector {
+	return f.SolveVecTo(NewVector(b.Len()), b)
+}
+
+// SolveVecTo stores the solution x of A*x = b in dst and returns dst,
+// without allocating. dst may be b.
+func (f *LU) SolveVecTo(dst, b *Vector) *Vector {
+	if n, _ := f.lu.Len(); b.Len() != n || dst.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	copyVector(dst, b)
+	f.solve(dst)
+	return dst
+}
+
+// SolveMat returns the solution X of A*X = B for the factorized
+// matrix A, solving for all columns of B with the one factorization.
+func (f *LU) SolveMat(b *Matrix) *Matrix {
+	n, m := b.Len()
+	if k, _ := f.lu.Len(); n != k {
+		panic("incompatible matrix sizes")
+	}
+	x := b.Copy()
+	for j := 0; j < m; j++ {
+		f.solve(x.Col(j))
+	}
+	return x
+}
+
+// L returns the unit lower triangular factor L.
+func (f *LU) L() *Matrix {
+	n, _ := f.lu.Len()
+	l := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			l[i, j] = f.lu[i, j]
+		}
+		l[i, i] = 1
+	}
+	return l
+}
+
+// U returns the upper triangular factor U.
+func (f *LU) U() *Matrix {
+	n, _ := f.lu.Len()
+	u := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			u[i, j] = f.lu[i, j]
+		}
+	}
+	return u
+}
+
+// Piv returns the row permutation: row i of P*A is row Piv()[i] of A.
+func (f *LU) Piv() []int { return f.piv }
+
+// solve overwrites x with the solution y of A*y = x.
+// Since P*A = L*U, y = U⁻¹ * L⁻¹ * P*x.
+func (f *LU) solve(x *Vector) {
+	for k, p := range f.swaps {
+		t := x[k]
+		x[k] = x[p]
+		x[p] = t
+	}
+	f.lu.solveTriangular(x, false, true)
+	f.lu.solveTriangular(x, true, false)
+}
+
+// solveTrans overwrites x with the solution y of Aᵀ*y = x.
+// Since Aᵀ = Uᵀ * Lᵀ * P, y = Pᵀ * Lᵀ⁻¹ * Uᵀ⁻¹ * x.
+func (f *LU) solveTrans(x *Vector) {
+	lut := f.lu.Transpose()
+	lut.solveTriangular(x, false, false)
+	lut.solveTriangular(x, true, true)
+	for k := len(f.swaps) - 1; k >= 0; k-- {
+		p := f.swaps[k]
+		t := x[k]
+		x[k] = x[p]
+		x[p] = t
+	}
+}
+
+// RCond returns an estimate of the reciprocal of the 1-norm condition
+// number 1/(‖A‖₁ * ‖A⁻¹‖₁) of the factorized matrix A. A value near 1
+// indicates a well-conditioned matrix, a value near the machine
+// epsilon (or 0) an ill-conditioned (or singular) one. The estimate
+// costs a few triangular solves.
+func (f *LU) RCond() T {
+	n, _ := f.lu.Len()
+	return rcond(n, f.anorm, f.solve, f.solveTrans)
+}
+
+// LogDet returns the natural logarithm of the absolute value of the
+// determinant of the factorized matrix, and the sign of the determinant
+// (-1 or +1). Unlike the determinant itself, the result does not
+// overflow or underflow for large matrices.
+func (f *LU) LogDet() (logAbs T, sign int) {
+	sign = f.sign
+	n, _ := f.lu.Len()
+	for i := 0; i < n; i++ {
+		u := f.lu[i, i]
+		switch {
+		case u == 0:
+			return T(math.Inf(-1)), 0
+		case u < 0:
+			sign = -sign
+		}
+		logAbs += T(math.Log(math.Abs(float64(u))))
+	}
+	return
+}
+
+// LogDet returns the natural logarithm of the absolute value of the
+// determinant of the square matrix a, and the sign of the determinant.
+// If a is singular, the result is (-Inf, 0).
+func (a *Matrix) LogDet() (logAbs T, sign int) {
+	f, _ := luDecompose(a, nil)
+	return f.LogDet()
+}
+
+// Det returns the determinant of the factorized matrix.
+func (f *LU) Det() T {
+	d := T(f.sign)
+	n, _ := f.lu.Len()
+	for i := 0; i < n; i++ {
+		d *= f.lu[i, i]
+	}
+	if d == 0 {
+		return 0 // not -0
+	}
+	return d
+}
+
+// Det returns the determinant of the square matrix a, computed from
+// its LU factorization; it is 0 if a is (exactly) singular. The
+// determinant easily overflows or underflows for large matrices; if
+// so, use LogDet.
+func (a *Matrix) Det() T {
+	f, _ := luDecompose(a, nil)
+	return f.Det()
+}
+
+// Inverse returns the inverse of the factorized matrix.
+func (f *LU) Inverse() *Matrix {
+	n, _ := f.lu.Len()
+	x := NewMatrix(n, n)
+	for j := 0; j < n; j++ {
+		c := x.Col(j)
+		c[j] = 1
+		f.solve(c)
+	}
+	return x
+}
+
+// Inverse returns the inverse of the square matrix a, computed from
+// its LU factorization. If a is (exactly) singular, the result is
+// ErrSingular. A nearly singular a yields an inaccurate inverse; check
+// RCond of the factorization if that matters. To solve linear systems,
+// SolveVec and SolveMat of the factorization are faster and more
+// accurate than multiplying by the inverse.
+func (a *Matrix) Inverse() (*Matrix, error) {
+	f, err := a.LU()
+	if err != nil {
+		return nil, err
+	}
+	return f.Inverse(), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrNotFinite is returned for matrices with NaN or infinite elements
+// by functions that need finite ones.
+var ErrNotFinite = errors.New("matrix has non-finite elements")
+
+// nearestSPDRounds is the number of times NearestSPD increases δ
+// before giving up.
+const nearestSPDRounds = 50
+
+// NearestSPD returns a symmetric positive definite matrix close to the
+// square matrix a, for repairing covariance or Hessian estimates that
+// lost definiteness to rounding or missing data. Following Higham
+// ("Computing a nearest symmetric positive semidefinite matrix",
+// 1988), the nearest (in the Frobenius norm) symmetric positive
+// semidefinite matrix is obtained from the eigendecomposition of the
+// symmetric part (a + aᵀ)/2 by clipping negative eigenvalues. To make
+// the result definite, the eigenvalues are clipped to a small
+// δ = n * ε * max |λ| instead of 0, and δ is increased until the
+// Cholesky factorization of the result succeeds, at most 50 times,
+// after which the result is ErrNoConvergence. A matrix that is
+// already symmetric positive definite is returned (as a copy) with
+// just its eigenvalues below δ raised. If a has NaN or infinite
+// elements, the result is ErrNotFinite.
+func (a *Matrix) NearestSPD() (*Matrix, error) {
+	MustBeSquare("NearestSPD", a)
+	n, _ := a.Len()
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if x := float64(a[i, j]); math.IsNaN(x) || math.IsInf(x, 0) {
+				return nil, ErrNotFinite
+			}
+		}
+	}
+	b := symmetrize(a.Add(a.Transpose()).Scale(0.5))
+	values, v, err := b.EigenSym()
+	if err != nil {
+		return nil, err
+	}
+	var max T
+	for i := 0; i < n; i++ {
+		if t := abs(values[i]); t > max {
+			max = t
+		}
+	}
+	delta := T(n) * epsilon * max
+	if delta == 0 {
+		delta = epsilon
+	}
+	for round := 0; round < nearestSPDRounds; round++ {
+		d := NewVector(n)
+		for i := 0; i < n; i++ {
+			d[i] = values[i]
+			if d[i] < delta {
+				d[i] = delta
+			}
+		}
+		// x = v * diag(d) * vᵀ
+		x := symmetrize(v.MulDiag(NewDiagonal(d)) * v.Transpose())
+		if _, err := x.Cholesky(); err == nil {
+			return x, nil
+		}
+		delta *= 4
+	}
+	return nil, ErrNoConvergence
+}
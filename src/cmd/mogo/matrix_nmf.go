@@ -0,0 +1,153 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "math/rand"
+
+// An NMFMethod selects the update rule used by NMF.
+type NMFMethod int
+
+const (
+	NMFMultiplicative NMFMethod = iota // Lee-Seung multiplicative updates
+	NMFHALS                            // hierarchical alternating least squares
+)
+
+// NMFOptions controls NMF.
+// A nil *NMFOptions is equivalent to the zero value.
+type NMFOptions struct {
+	Method NMFMethod
+	// Rand is the source of randomness for the random start; nil
+	// means a source with a fixed seed, so that runs are reproducible.
+	Rand *rand.Rand
+	// Checkpointer, if not nil, is called after every iteration with
+	// the factors w and h as state.
+	Checkpointer Checkpointer
+	// Resume, if not nil, is a checkpoint of a previous run for the
+	// same matrix to continue from, instead of a random start.
+	Resume *Checkpoint
+}
+
+// nmfEps keeps denominators and factor elements away from zero.
+const nmfEps = 1e-16
+
+// NMF computes a non-negative matrix factorization a ≈ w*h of the n×m
+// matrix a with non-negative elements into non-negative factors w
+// (n×k) and h (k×m), performing iters iterations to decrease the
+// Frobenius norm |a - w*h| from a random start.
+//
+// The default multiplicative updates are simple and decrease the
+// error monotonically but may converge slowly; HALS (opts.Method =
+// NMFHALS) updates one component at a time and typically converges
+// much faster at the same cost per iteration.
+//
+// An error is only returned by a Checkpointer.
+func (a *Matrix) NMF(k, iters int, opts *NMFOptions) (w, h *Matrix, err error) {
+	if opts == nil {
+		opts = new(NMFOptions)
+	}
+	n, m := a.Len()
+	if k < 1 {
+		panic("invalid rank")
+	}
+	var mean T
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			if a[i, j] < 0 {
+				panic("negative matrix element")
+			}
+			mean += a[i, j]
+		}
+	}
+	if n > 0 && m > 0 {
+		mean /= T(n * m)
+	}
+
+	// random start with w*h of the same magnitude as a
+	rnd := opts.Rand
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(1))
+	}
+	scale := sqrt(mean / T(k))
+	w, h = NewMatrix(n, k), NewMatrix(k, m)
+	for i := 0; i < n; i++ {
+		for l := 0; l < k; l++ {
+			w[i, l] = scale * T(rnd.Float64())
+		}
+	}
+	for l := 0; l < k; l++ {
+		for j := 0; j < m; j++ {
+			h[l, j] = scale * T(rnd.Float64())
+		}
+	}
+
+	it := 0
+	if c := opts.Resume; c != nil {
+		s := checkpointState(c, 2)
+		w, h = checkpointMatrix(s[0], n, k), checkpointMatrix(s[1], k, m)
+		it = c.Iter
+	}
+	for ; it < iters; it++ {
+		switch opts.Method {
+		case NMFMultiplicative:
+			// h = h ∘ (wᵀa) / (wᵀw h); w = w ∘ (a hᵀ) / (w h hᵀ)
+			wt := w.Transpose()
+			multiplicativeUpdate(h, wt*a, (wt*w)*h)
+			ht := h.Transpose()
+			multiplicativeUpdate(w, a*ht, w*(h*ht))
+		case NMFHALS:
+			wt := w.Transpose()
+			halsUpdate(h, wt*a, wt*w)
+			// the update of w is that of h for the transposed problem
+			halsUpdate(w.Transpose(), h*a.Transpose(), h*h.Transpose())
+		default:
+			panic("invalid NMF method")
+		}
+		if cp := opts.Checkpointer; cp != nil {
+			if err := cp.Checkpoint(&Checkpoint{it + 1, []*Matrix{w, h}}); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return w, h, nil
+}
+
+// multiplicativeUpdate sets x = x ∘ num / den elementwise.
+func multiplicativeUpdate(x, num, den *Matrix) {
+	n, m := x.Len()
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			x[i, j] = x[i, j] * num[i, j] / (den[i, j] + nmfEps)
+		}
+	}
+}
+
+// halsUpdate updates the rows of the k×m factor h one at a time,
+// given wta = wᵀa and wtw = wᵀw for the other factor w: row l is
+// set to the non-negative least-squares solution with all other
+// rows fixed,
+//
+//	h[l, :] = max(0, h[l, :] + (wta[l, :] - wtw[l, :]*h) / wtw[l, l]).
+func halsUpdate(h, wta, wtw *Matrix) {
+	k, m := h.Len()
+	for l := 0; l < k; l++ {
+		d := wtw[l, l]
+		if d < nmfEps {
+			d = nmfEps
+		}
+		for j := 0; j < m; j++ {
+			t := wta[l, j]
+			for p := 0; p < k; p++ {
+				t -= wtw[l, p] * h[p, j]
+			}
+			x := h[l, j] + t/d
+			if x < nmfEps {
+				x = nmfEps
+			}
+			h[l, j] = x
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "math"
+
+// Norm returns the p-norm (Σ |x[i]|^p)^(1/p) of x for p >= 1; p may
+// be +Inf for the ∞-norm, the maximum absolute value. The computation
+// is scaled by the ∞-norm, so it does not overflow or underflow.
+func (x *Vector) Norm(p T) T {
+	switch {
+	case p == 1:
+		return vecNorm1(x)
+	case math.IsInf(float64(p), 1):
+		return vecNormInf(x)
+	case p < 1 || p != p:
+		panic("invalid norm")
+	}
+	max := vecNormInf(x)
+	if max == 0 || math.IsInf(float64(max), 1) {
+		return max
+	}
+	var s T
+	for i := 0; i < x.Len(); i++ {
+		t := abs(x[i]) / max
+		if p == 2 {
+			s += t * t
+		} else {
+			s += T(math.Pow(float64(t), float64(p)))
+		}
+	}
+	if p == 2 {
+		return max * sqrt(s)
+	}
+	return max * T(math.Pow(float64(s), float64(1/p)))
+}
+
+// A MatrixNorm selects the norm computed by Matrix.Norm.
+type MatrixNorm int
+
+const (
+	NormFrobenius MatrixNorm = iota // square root of the sum of squares
+	NormOne                         // maximum absolute column sum
+	NormInf                         // maximum absolute row sum
+	Norm2                           // largest singular value; computed via the SVD and expensive
+)
+
+// Norm returns the norm of a selected by kind.
+func (a *Matrix) Norm(kind MatrixNorm) T {
+	n, m := a.Len()
+	switch kind {
+	case NormFrobenius:
+		// scaled like Vector.Norm
+		var max T
+		for i := 0; i < n; i++ {
+			if t := vecNormInf(a.Row(i)); t > max {
+				max = t
+			}
+		}
+		if max == 0 || math.IsInf(float64(max), 1) {
+			return max
+		}
+		var s T
+		for i := 0; i < n; i++ {
+			for j := 0; j < m; j++ {
+				t := a[i, j] / max
+				s += t * t
+			}
+		}
+		return max * sqrt(s)
+	case NormOne:
+		return norm1(a)
+	case NormInf:
+		return norm1(a.Transpose())
+	case Norm2:
+		if n == 0 || m == 0 {
+			return 0
+		}
+		_, s, _ := svd(a)
+		return s[0]
+	}
+	panic("invalid norm")
+}
+
+// Cond returns an estimate of the 1-norm condition number
+// ‖a‖₁ * ‖a⁻¹‖₁ of the square matrix a, from its LU factorization and
+// the estimate of LU.RCond. It is +Inf if a is singular. The relative
+// error of the solution of a linear system with a is bounded by about
+// Cond() times the relative error of the data.
+func (a *Matrix) Cond() T {
+	f, singular := luDecompose(a, nil)
+	if singular {
+		return T(math.Inf(1))
+	}
+	if r := f.RCond(); r > 0 {
+		return 1 / r
+	}
+	return T(math.Inf(1))
+}
@@ -0,0 +1,485 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A MatrixReader is a matrix whose elements can be read, such as a
+// *Matrix or one of the packed matrix types SymMatrix, TriMatrix, and
+// BandMatrix, which store only the elements their structure requires.
+type MatrixReader interface {
+	Len() (int, int)
+	[] (i, j int) T
+}
+
+// A SymMatrix is a symmetric n×n matrix of which only the lower
+// triangle is stored, packed row by row: n*(n+1)/2 elements instead
+// of n*n. Setting element (i, j) also sets element (j, i).
+type SymMatrix struct {
+	n    int
+	data []T
+}
+
+func NewSymMatrix(n int) *SymMatrix {
+	if n < 0 {
+		panic("invalid length")
+	}
+	return &SymMatrix{n, make([]T, n*(n+1)/2)}
+}
+
+func (s *SymMatrix) addr(i, j int) *T {
+	if boundsChecks && (uint(i) >= uint(s.n) || uint(j) >= uint(s.n)) {
+		panic("index out of bounds")
+	}
+	if j > i {
+		i, j = j, i
+	}
+	return &s.data[i*(i+1)/2+j]
+}
+
+func (s *SymMatrix) Len() (int, int)    { return s.n, s.n }
+func (s *SymMatrix) [] (i, j int) T     { return *s.addr(i, j) }
+func (s *SymMatrix) []= (i, j int, x T) { *s.addr(i, j) = x }
+func (s *SymMatrix) Dense() *Matrix     { return denseOf(s) }
+
+// MulVec returns the matrix-vector product s*x, visiting each stored
+// element once.
+func (s *SymMatrix) MulVec(x *Vector) *Vector {
+	n := s.n
+	if x.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(n)
+	for i := 0; i < n; i++ {
+		row := s.data[i*(i+1)/2 : i*(i+1)/2+i+1]
+		xi, t := x[i], y[i]
+		for j, v := range row[:i] {
+			t += v * x[j]
+			y[j] += v * xi
+		}
+		y[i] = t + row[i]*xi
+	}
+	return y
+}
+
+// PackSym returns the symmetric matrix with the lower triangle of the
+// square matrix a; the upper triangle of a is ignored.
+func (a *Matrix) PackSym() *SymMatrix {
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	s := NewSymMatrix(n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			s[i, j] = a[i, j]
+		}
+	}
+	return s
+}
+
+// Solve solves s*x = b. If s is positive definite, it uses a Cholesky
+// factorization s = L*Lᵀ computed in packed storage (as LAPACK's
+// PPTRF and PPTRS do), which needs a temporary of n*(n+1)/2 elements
+// for L. Otherwise s is expanded into a dense n×n temporary and solved
+// with LU; if s is singular, the result is ErrSingular.
+func (s *SymMatrix) Solve(b *Vector) (*Vector, error) {
+	n := s.n
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	if l, ok := s.cholesky(); ok {
+		return solvePackedCholesky(l, n, b), nil
+	}
+	f, err := s.Dense().LU()
+	if err != nil {
+		return nil, err
+	}
+	return f.SolveVec(b), nil
+}
+
+// cholesky returns the lower triangular Cholesky factor L of s, packed
+// row by row like s.data. The result reports whether s is positive
+// definite; if not, L is incomplete.
+func (s *SymMatrix) cholesky() ([]T, bool) {
+	n := s.n
+	l := make([]T, len(s.data))
+	for i := 0; i < n; i++ {
+		li := l[i*(i+1)/2 : i*(i+1)/2+i+1]
+		ai := s.data[i*(i+1)/2 : i*(i+1)/2+i+1]
+		for j := 0; j < i; j++ {
+			lj := l[j*(j+1)/2 : j*(j+1)/2+j+1]
+			li[j] = (ai[j] - dotUnitary(li[:j], lj[:j])) / lj[j]
+		}
+		d := ai[i] - dotUnitary(li[:i], li[:i])
+		if !(d > 0) {
+			return l, false
+		}
+		li[i] = sqrt(d)
+	}
+	return l, true
+}
+
+// solvePackedCholesky solves L*Lᵀ*x = b for the n×n lower triangular
+// matrix L packed row by row in l.
+func solvePackedCholesky(l []T, n int, b *Vector) *Vector {
+	x := b.Copy()
+	// L*y = b
+	for i := 0; i < n; i++ {
+		li := l[i*(i+1)/2 : i*(i+1)/2+i+1]
+		t := x[i]
+		for k, v := range li[:i] {
+			t -= v * x[k]
+		}
+		x[i] = t / li[i]
+	}
+	// Lᵀ*x = y, using row i of L as column i of Lᵀ
+	for i := n - 1; i >= 0; i-- {
+		li := l[i*(i+1)/2 : i*(i+1)/2+i+1]
+		xi := x[i] / li[i]
+		x[i] = xi
+		for k, v := range li[:i] {
+			x[k] -= v * xi
+		}
+	}
+	return x
+}
+
+// A TriMatrix is an upper or lower triangular n×n matrix of which only
+// the triangle is stored, packed row by row. Elements outside the
+// triangle are zero and cannot be set.
+type TriMatrix struct {
+	n     int
+	upper bool
+	data  []T
+}
+
+func NewTriMatrix(n int, upper bool) *TriMatrix {
+	if n < 0 {
+		panic("invalid length")
+	}
+	return &TriMatrix{n, upper, make([]T, n*(n+1)/2)}
+}
+
+// index returns the position of element (i, j) in t.data, or -1 if it
+// is outside the triangle.
+func (t *TriMatrix) index(i, j int) int {
+	if boundsChecks && (uint(i) >= uint(t.n) || uint(j) >= uint(t.n)) {
+		panic("index out of bounds")
+	}
+	if t.upper {
+		if j < i {
+			return -1
+		}
+		// rows 0..i-1 hold n + (n-1) + ... + (n-i+1) elements
+		return i*t.n - i*(i-1)/2 + j - i
+	}
+	if j > i {
+		return -1
+	}
+	return i*(i+1)/2 + j
+}
+
+func (t *TriMatrix) Len() (int, int) { return t.n, t.n }
+
+// Upper reports whether t is upper triangular.
+func (t *TriMatrix) Upper() bool { return t.upper }
+
+func (t *TriMatrix) [] (i, j int) T {
+	if k := t.index(i, j); k >= 0 {
+		return t.data[k]
+	}
+	return 0
+}
+
+func (t *TriMatrix) []= (i, j int, x T) {
+	k := t.index(i, j)
+	if k < 0 {
+		panic("element outside triangle")
+	}
+	t.data[k] = x
+}
+
+func (t *TriMatrix) Dense() *Matrix { return denseOf(t) }
+
+// MulVec returns the matrix-vector product t*x.
+func (t *TriMatrix) MulVec(x *Vector) *Vector {
+	n := t.n
+	if x.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(n)
+	for i := 0; i < n; i++ {
+		j0, j1 := 0, i+1
+		if t.upper {
+			j0, j1 = i, n
+		}
+		row := t.data[t.index(i, j0) : t.index(i, j0)+j1-j0]
+		var s T
+		for k, v := range row {
+			s += v * x[j0+k]
+		}
+		y[i] = s
+	}
+	return y
+}
+
+// PackTri returns the upper (if upper is set) or lower triangle of the
+// square matrix a as a triangular matrix.
+func (a *Matrix) PackTri(upper bool) *TriMatrix {
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	t := NewTriMatrix(n, upper)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if k := t.index(i, j); k >= 0 {
+				t.data[k] = a[i, j]
+			}
+		}
+	}
+	return t
+}
+
+// Solve solves t*x = b by substitution in O(n²) time. If t has a zero
+// on the diagonal, the result is ErrSingular.
+func (t *TriMatrix) Solve(b *Vector) (*Vector, error) {
+	n := t.n
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	x := b.Copy()
+	for k := 0; k < n; k++ {
+		i := k
+		if t.upper {
+			i = n - 1 - k
+		}
+		d := t.data[t.index(i, i)]
+		if d == 0 {
+			return nil, ErrSingular
+		}
+		s := x[i]
+		if t.upper {
+			row := t.data[t.index(i, i)+1 : t.index(i, i)+n-i]
+			for l, v := range row {
+				s -= v * x[i+1+l]
+			}
+		} else {
+			row := t.data[t.index(i, 0):t.index(i, i)]
+			for l, v := range row {
+				s -= v * x[l]
+			}
+		}
+		x[i] = s / d
+	}
+	return x, nil
+}
+
+// A BandMatrix is an n×m matrix whose nonzero elements lie within kl
+// subdiagonals and ku superdiagonals: a[i, j] = 0 unless
+// -kl <= j-i <= ku. Row i of the band is stored in
+// data[i*(kl+ku+1):(i+1)*(kl+ku+1)], starting at column i-kl.
+// Elements outside the band are zero and cannot be set.
+type BandMatrix struct {
+	len    Shape
+	kl, ku int
+	data   []T
+}
+
+func NewBandMatrix(n, m, kl, ku int) *BandMatrix {
+	if n < 0 || m < 0 || kl < 0 || ku < 0 {
+		panic("invalid length")
+	}
+	return &BandMatrix{Shape{n, m}, kl, ku, make([]T, n*(kl+ku+1))}
+}
+
+// index returns the position of element (i, j) in b.data, or -1 if it
+// is outside the band.
+func (b *BandMatrix) index(i, j int) int {
+	if boundsChecks && (uint(i) >= uint(b.len[0]) || uint(j) >= uint(b.len[1])) {
+		panic("index out of bounds")
+	}
+	if d := j - i; d < -b.kl || d > b.ku {
+		return -1
+	}
+	return i*(b.kl+b.ku+1) + j - i + b.kl
+}
+
+func (b *BandMatrix) Len() (int, int) { return b.len[0], b.len[1] }
+
+// Bandwidth returns the number of subdiagonals and superdiagonals of b.
+func (b *BandMatrix) Bandwidth() (kl, ku int) { return b.kl, b.ku }
+
+func (b *BandMatrix) [] (i, j int) T {
+	if k := b.index(i, j); k >= 0 {
+		return b.data[k]
+	}
+	return 0
+}
+
+func (b *BandMatrix) []= (i, j int, x T) {
+	k := b.index(i, j)
+	if k < 0 {
+		panic("element outside band")
+	}
+	b.data[k] = x
+}
+
+func (b *BandMatrix) Dense() *Matrix { return denseOf(b) }
+
+// cols returns the range [j0, j1) of columns of row i within the band.
+func (b *BandMatrix) cols(i int) (j0, j1 int) {
+	j0, j1 = i-b.kl, i+b.ku+1
+	if j0 < 0 {
+		j0 = 0
+	}
+	if j1 > b.len[1] {
+		j1 = b.len[1]
+	}
+	return
+}
+
+// MulVec returns the matrix-vector product b*x in O(n*(kl+ku)) time.
+func (b *BandMatrix) MulVec(x *Vector) *Vector {
+	n, m := b.Len()
+	if x.Len() != m {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(n)
+	for i := 0; i < n; i++ {
+		var s T
+		for j, j1 := b.cols(i); j < j1; j++ {
+			s += b.data[b.index(i, j)] * x[j]
+		}
+		y[i] = s
+	}
+	return y
+}
+
+// PackBand returns the band of a with kl subdiagonals and ku
+// superdiagonals as a band matrix; elements outside it are ignored.
+func (a *Matrix) PackBand(kl, ku int) *BandMatrix {
+	n, m := a.Len()
+	b := NewBandMatrix(n, m, kl, ku)
+	for i := 0; i < n; i++ {
+		for j, j1 := b.cols(i); j < j1; j++ {
+			b.data[b.index(i, j)] = a[i, j]
+		}
+	}
+	return b
+}
+
+// Solve solves b*x = rhs for the square band matrix b with Gaussian
+// elimination with partial pivoting in O(n*kl*(kl+ku)) time; pivoting
+// widens the upper band of the factor to kl+ku. If b is (exactly)
+// singular, the result is ErrSingular.
+func (b *BandMatrix) Solve(rhs *Vector) (*Vector, error) {
+	n, m := b.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	if rhs.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	kl, ku := b.kl, b.ku
+	w := NewBandMatrix(n, n, kl, kl+ku)
+	for i := 0; i < n; i++ {
+		for j, j1 := b.cols(i); j < j1; j++ {
+			w.data[w.index(i, j)] = b.data[b.index(i, j)]
+		}
+	}
+	x := rhs.Copy()
+	for k := 0; k < n; k++ {
+		// find pivot among the kl rows below
+		i1 := k + kl + 1
+		if i1 > n {
+			i1 = n
+		}
+		p := k
+		for i := k + 1; i < i1; i++ {
+			if abs(w[i, k]) > abs(w[p, k]) {
+				p = i
+			}
+		}
+		_, j1 := w.cols(k)
+		if p != k {
+			for j := k; j < j1; j++ {
+				t := w[k, j]
+				w[k, j] = w[p, j]
+				w[p, j] = t
+			}
+			t := x[k]
+			x[k] = x[p]
+			x[p] = t
+		}
+		d := w[k, k]
+		if d == 0 {
+			return nil, ErrSingular
+		}
+		for i := k + 1; i < i1; i++ {
+			l := w[i, k] / d
+			if l == 0 {
+				continue
+			}
+			for j := k + 1; j < j1; j++ {
+				w[i, j] -= l * w[k, j]
+			}
+			x[i] -= l * x[k]
+		}
+	}
+	// back substitution with the upper triangular factor
+	for i := n - 1; i >= 0; i-- {
+		s := x[i]
+		for j, j1 := i+1, i+kl+ku+1; j < j1 && j < n; j++ {
+			s -= w[i, j] * x[j]
+		}
+		x[i] = s / w[i, i]
+	}
+	return x, nil
+}
+
+// denseOf returns the elements of a as a (dense) *Matrix.
+func denseOf(a MatrixReader) *Matrix {
+	n, m := a.Len()
+	c := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[i, j] = a[i, j]
+		}
+	}
+	return c
+}
+
+// MulAny returns the product a*b of two matrices in any storage. It
+// uses the structure of packed matrices: with a band or triangular
+// matrix a, only the stored elements of a are visited.
+func MulAny(a, b MatrixReader) *Matrix {
+	n, k := a.Len()
+	bk, m := b.Len()
+	if k != bk {
+		panic("incompatible matrix sizes")
+	}
+	if a, ok := a.(*Matrix); ok {
+		if b, ok := b.(*Matrix); ok {
+			return a * b
+		}
+	}
+	bd, ok := b.(*Matrix)
+	if !ok {
+		bd = denseOf(b)
+	}
+	type mulVecer interface {
+		MulVec(x *Vector) *Vector
+	}
+	if a, ok := a.(mulVecer); ok {
+		c := NewMatrix(n, m)
+		for j := 0; j < m; j++ {
+			copyVector(c.Col(j), a.MulVec(bd.Col(j).Copy()))
+		}
+		return c
+	}
+	return MulTo(NewMatrix(n, m), denseOf(a), bd)
+}
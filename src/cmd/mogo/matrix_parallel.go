@@ -0,0 +1,219 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// atomicAdd atomically adds v to *p, by a compare-and-swap loop on the
+// bits of *p; T must be a 32- or 64-bit floating-point type.
+func atomicAdd(p *T, v T) {
+	switch unsafe.Sizeof(*p) {
+	case 4:
+		u := (*uint32)(unsafe.Pointer(p))
+		for {
+			old := atomic.LoadUint32(u)
+			sum := math.Float32bits(math.Float32frombits(old) + float32(v))
+			if atomic.CompareAndSwapUint32(u, old, sum) {
+				return
+			}
+		}
+	case 8:
+		u := (*uint64)(unsafe.Pointer(p))
+		for {
+			old := atomic.LoadUint64(u)
+			sum := math.Float64bits(math.Float64frombits(old) + float64(v))
+			if atomic.CompareAndSwapUint64(u, old, sum) {
+				return
+			}
+		}
+	default:
+		panic("atomicAdd: unsupported element size")
+	}
+}
+
+// AtomicAdd atomically adds v to x[i]. Multiple goroutines may call
+// AtomicAdd concurrently on the same vector, as long as no other
+// writes to x happen at the same time.
+func (x *Vector) AtomicAdd(i int, v T) { atomicAdd(x.addr(i), v) }
+
+// AtomicAdd atomically adds v to a[i, j]. Multiple goroutines may call
+// AtomicAdd concurrently on the same matrix (e.g., to scatter-add into
+// a shared result), as long as no other writes to a happen at the same
+// time.
+func (a *Matrix) AtomicAdd(i, j int, v T) { atomicAdd(a.addr(i, j), v) }
+
+// parallelFor calls body(lo, hi) for consecutive chunks [lo, hi) of
+// [0, n) of (at most) grain elements each, using up to maxWorkers()
+// goroutines. It stops handing out chunks once ctx is done and then
+// returns ctx.Err(). If body panics, parallelFor waits for the other
+// goroutines to finish their current chunk and then panics with the
+// same value in the calling goroutine.
+func parallelFor(ctx context.Context, n, grain int, body func(lo, hi int)) error {
+	if grain < 1 {
+		grain = 1
+	}
+	chunks := (n + grain - 1) / grain
+	workers := maxWorkers()
+	if workers > chunks {
+		workers = chunks
+	}
+
+	var (
+		next     int64 // next chunk to hand out
+		stop     int32 // set if a worker panicked
+		mu       sync.Mutex
+		panicked bool
+		pval     interface{}
+		wg       sync.WaitGroup
+	)
+	worker := func() {
+		defer wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				mu.Lock()
+				if !panicked {
+					panicked, pval = true, r
+				}
+				mu.Unlock()
+				atomic.StoreInt32(&stop, 1)
+			}
+		}()
+		for atomic.LoadInt32(&stop) == 0 && ctx.Err() == nil {
+			c := int(atomic.AddInt64(&next, 1) - 1)
+			if c >= chunks {
+				return
+			}
+			lo := c * grain
+			hi := lo + grain
+			if hi > n {
+				hi = n
+			}
+			body(lo, hi)
+		}
+	}
+	wg.Add(workers)
+	for w := 1; w < workers; w++ {
+		go worker()
+	}
+	if workers > 0 {
+		worker() // use the calling goroutine as well
+	}
+	wg.Wait()
+
+	if panicked {
+		panic(pval)
+	}
+	return ctx.Err()
+}
+
+// rowGrain returns the number of rows per chunk for parallel row
+// processing of n rows; a few chunks per worker balance the load.
+func rowGrain(n int) int {
+	return n/(4*maxWorkers()) + 1
+}
+
+// maxProcs is the limit set by SetMaxProcs; 0 means GOMAXPROCS.
+var maxProcs int32
+
+// SetMaxProcs limits the number of goroutines used by the parallel
+// operations of the package (ParallelApply, Aggregate, ParallelMul)
+// to n, and returns the previous limit. If n <= 0, the limit is
+// GOMAXPROCS, which is the default.
+func SetMaxProcs(n int) (prev int) {
+	if n < 0 {
+		n = 0
+	}
+	return int(atomic.SwapInt32(&maxProcs, int32(n)))
+}
+
+// maxWorkers returns the number of goroutines parallel operations use.
+func maxWorkers() int {
+	if n := int(atomic.LoadInt32(&maxProcs)); n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// parallelMulThreshold is the number of multiply-adds n*m*p below
+// which ParallelMul multiplies serially, since goroutine startup would
+// dominate.
+const parallelMulThreshold = 1 << 18
+
+// ParallelMul returns the matrix product a*b, computed by multiple
+// goroutines (see SetMaxProcs) that each compute a block of rows of
+// the result. Small products are computed serially.
+func ParallelMul(a, b *Matrix) *Matrix {
+	MustMatchMul("ParallelMul", a, b)
+	n, m := a.Len()
+	_, p := b.Len()
+	c := NewMatrix(n, p)
+	if n*m*p < parallelMulThreshold || maxWorkers() == 1 {
+		mulKernel(c, a, b)
+		return c
+	}
+	parallelFor(context.Background(), n, rowGrain(n), func(lo, hi int) {
+		mulKernel(c.Slice(lo, hi, 0, p), a.Slice(lo, hi, 0, m), b)
+	})
+	return c
+}
+
+// ParallelApply calls f(i, a.Row(i)) for each row i of a, distributing
+// the rows across multiple goroutines. f may modify the row it is
+// given. If f panics, ParallelApply panics with the same value after
+// all goroutines stopped.
+func (a *Matrix) ParallelApply(f func(row int, v *Vector)) {
+	a.ParallelApplyContext(context.Background(), f)
+}
+
+// ParallelApplyContext is like ParallelApply but stops processing
+// further rows once ctx is done, in which case it returns ctx.Err().
+func (a *Matrix) ParallelApplyContext(ctx context.Context, f func(row int, v *Vector)) error {
+	n, _ := a.Len()
+	return parallelFor(ctx, n, rowGrain(n), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			f(i, a.Row(i))
+		}
+	})
+}
+
+// aggregateGrain is the number of rows reduced per chunk by Aggregate.
+// It is fixed so that the reduction order does not depend on the
+// number of goroutines.
+const aggregateGrain = 64
+
+// Aggregate maps each row of a to a value using mapper and combines
+// the values using reducer; the rows are processed in parallel. The
+// reduction order is deterministic: rows are reduced left-to-right in
+// fixed-size chunks, and the chunk results are again reduced
+// left-to-right. Aggregate returns nil if a has no rows.
+func (a *Matrix) Aggregate(mapper func(*Vector) interface{}, reducer func(x, y interface{}) interface{}) interface{} {
+	n, _ := a.Len()
+	results := make([]interface{}, (n+aggregateGrain-1)/aggregateGrain)
+	parallelFor(context.Background(), n, aggregateGrain, func(lo, hi int) {
+		r := mapper(a.Row(lo))
+		for i := lo + 1; i < hi; i++ {
+			r = reducer(r, mapper(a.Row(i)))
+		}
+		results[lo/aggregateGrain] = r
+	})
+	var r interface{}
+	for k, x := range results {
+		if k == 0 {
+			r = x
+		} else {
+			r = reducer(r, x)
+		}
+	}
+	return r
+}
@@ -0,0 +1,129 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// SolvePoisson solves the Poisson equation -Δu = f with zero Dirichlet
+// boundary values on a uniform grid with spacing h. The n×m matrix f
+// holds the values of f at the interior grid points, and the result
+// holds the corresponding values of u. The 5-point discretization is
+// solved with the conjugate gradient method, preconditioned with an
+// incomplete Cholesky factorization. SolvePoisson also returns the
+// number of CG iterations.
+func SolvePoisson(f *Matrix, h T) (u *Matrix, iters int, err error) {
+	n, m := f.Len()
+	l := Laplacian2D(n, m, h, BoundaryDirichlet)
+	ic, err := NewIncompleteCholesky(l)
+	if err != nil {
+		return nil, 0, err
+	}
+	b := f.Copy()
+	x, iters, err := CG(l, &Vector{b.array, 0, n * m, 1}, &CGOptions{Precond: ic})
+	if err != nil {
+		return nil, iters, err
+	}
+	return &Matrix{x.array, 0, Shape{n, m}, Shape{m, 1}}, iters, nil
+}
+
+// heatmapRamp lists the characters used by Heatmap, from low to high.
+const heatmapRamp = " .:-=+*#%@"
+
+// Heatmap renders a to w as text, one character per element, with
+// increasingly dense characters for increasing values between the
+// minimum and the maximum finite element of a. NaN elements are shown
+// as '?', +Inf as '>', and -Inf as '<'.
+func (a *Matrix) Heatmap(w io.Writer) error {
+	n, m := a.Len()
+	min, max := T(math.Inf(1)), T(math.Inf(-1))
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			x := a[i, j]
+			if math.IsInf(float64(x), 0) {
+				continue
+			}
+			if x < min {
+				min = x
+			}
+			if x > max {
+				max = x
+			}
+		}
+	}
+	line := make([]byte, m+1)
+	line[m] = '\n'
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			x := a[i, j]
+			switch {
+			case x != x:
+				line[j] = '?'
+			case math.IsInf(float64(x), 1):
+				line[j] = '>'
+			case math.IsInf(float64(x), -1):
+				line[j] = '<'
+			case max == min:
+				line[j] = heatmapRamp[0]
+			default:
+				// halved so that max - min cannot overflow
+				k := int((x/2 - min/2) / (max/2 - min/2) * T(len(heatmapRamp)))
+				if k < 0 {
+					k = 0
+				}
+				if k >= len(heatmapRamp) {
+					k = len(heatmapRamp) - 1
+				}
+				line[j] = heatmapRamp[k]
+			}
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poissonDemo solves -Δu = f on the rectangle [0, 1] × [0, 2] for the
+// right-hand side f whose exact solution is u = sin(πx)·y(2-y),
+// reports the iteration count and the discretization error, and
+// renders the solution.
+func poissonDemo() {
+	const n, m = 15, 31
+	const h = 1.0 / (n + 1)
+	f := NewMatrix(n, m)
+	exact := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			x, y := float64(i+1)*h, float64(j+1)*h
+			s := math.Sin(math.Pi * x)
+			exact[i, j] = T(s * y * (2 - y))
+			f[i, j] = T(s * (math.Pi*math.Pi*y*(2-y) + 2))
+		}
+	}
+
+	u, iters, err := SolvePoisson(f, h)
+	if err != nil {
+		fmt.Println("Poisson:", err)
+		return
+	}
+	var maxErr T
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			if e := abs(u[i, j] - exact[i, j]); e > maxErr {
+				maxErr = e
+			}
+		}
+	}
+	fmt.Printf("Poisson on a %d×%d grid: %d CG iterations, max error %.2e\n", n, m, iters, maxErr)
+	u.Heatmap(os.Stdout)
+	fmt.Println()
+}
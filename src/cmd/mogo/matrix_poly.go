@@ -0,0 +1,87 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// PolyRoots returns the (complex) roots of the polynomial
+//
+//	p(x) = coeffs[0]*x**n + coeffs[1]*x**(n-1) + ... + coeffs[n]
+//
+// computed as the eigenvalues of its companion matrix. Multiple roots
+// are repeated according to their multiplicity. Leading zero
+// coefficients are ignored; if all coefficients are zero, the result
+// is empty.
+func PolyRoots(coeffs []T) ([]complex128, error) {
+	for len(coeffs) > 0 && coeffs[0] == 0 {
+		coeffs = coeffs[1:]
+	}
+	// trailing zero coefficients correspond to roots at 0
+	var zeros int
+	for len(coeffs) > 1 && coeffs[len(coeffs)-1] == 0 {
+		coeffs = coeffs[:len(coeffs)-1]
+		zeros++
+	}
+	n := len(coeffs) - 1
+	if n < 0 {
+		return nil, nil
+	}
+
+	// companion matrix of the monic polynomial p(x)/coeffs[0]
+	c := NewMatrix(n, n)
+	for j := 0; j < n; j++ {
+		c[0, j] = -coeffs[j+1] / coeffs[0]
+	}
+	for i := 1; i < n; i++ {
+		c[i, i-1] = 1
+	}
+	roots, err := c.Eigenvalues()
+	if err != nil {
+		return nil, err
+	}
+	for ; zeros > 0; zeros-- {
+		roots = append(roots, 0)
+	}
+	return roots, nil
+}
+
+// Vandermonde returns the n×(degree+1) Vandermonde matrix v of the n
+// elements of x, with powers decreasing from left to right:
+//
+//	v[i, j] = x[i]**(degree-j)
+//
+// so that v*c evaluates the polynomial with coefficients c (highest
+// degree first, as for PolyRoots) at the points x.
+func Vandermonde(x *Vector, degree int) *Matrix {
+	if degree < 0 {
+		panic("invalid degree")
+	}
+	n := x.Len()
+	v := NewMatrix(n, degree+1)
+	for i := 0; i < n; i++ {
+		p := T(1)
+		for j := degree; j >= 0; j-- {
+			v[i, j] = p
+			p *= x[i]
+		}
+	}
+	return v
+}
+
+// PolyFit returns the coefficients (highest degree first) of the
+// polynomial of the given degree that fits the points (x[i], y[i]) best
+// in the least-squares sense. If the points don't determine such a
+// polynomial uniquely (e.g., there are fewer distinct x values than
+// coefficients), the result is ErrSingular.
+func PolyFit(x, y *Vector, degree int) (*Vector, error) {
+	if x.Len() != y.Len() {
+		panic("incompatible vector lengths")
+	}
+	f := qrDecompose(Vandermonde(x, degree), true)
+	if f.Rank(-1) <= degree {
+		return nil, ErrSingular
+	}
+	return f.solve(y), nil
+}
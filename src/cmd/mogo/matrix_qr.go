@@ -0,0 +1,249 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "math"
+
+// A QR is the QR factorization A*P = Q*R of an n×m matrix A, where P
+// is a permutation matrix (the identity unless column pivoting was
+// used), Q is n×n orthogonal, and R is n×m upper trapezoidal. Q is
+// kept in compact form as a product of Householder reflections.
+type QR struct {
+	qr  *Matrix // R on and above the diagonal, Householder vectors below
+	tau []T     // Householder scalars
+	piv []int   // column i of A*P is column piv[i] of A
+}
+
+// QR computes the Householder QR factorization of a, without column
+// pivoting (P is the identity).
+func (a *Matrix) QR() *QR {
+	return qrDecompose(a, false)
+}
+
+// QRPivoted computes the QR factorization of a with column pivoting:
+// in each step, the remaining column with the largest norm is chosen
+// as the next pivot. As a result, the absolute values of the diagonal
+// elements of R are non-increasing, and their decay reveals the
+// numerical rank of a (see Rank). The leading columns of A*P form a
+// well-conditioned subset of the columns of a.
+func (a *Matrix) QRPivoted() *QR {
+	return qrDecompose(a, true)
+}
+
+// qrDecompose computes the Householder QR factorization of a,
+// with column pivoting if pivot is set.
+func qrDecompose(a *Matrix, pivot bool) *QR {
+	n, m := a.Len()
+	qr := a.Copy()
+	k := n
+	if m < k {
+		k = m
+	}
+	tau := make([]T, k)
+	piv := make([]int, m)
+	for j := range piv {
+		piv[j] = j
+	}
+
+	for l := 0; l < k; l++ {
+		if pivot {
+			// find the column with the largest remaining norm
+			p, max := l, T(-1)
+			for j := l; j < m; j++ {
+				var s T
+				for i := l; i < n; i++ {
+					s += qr[i, j] * qr[i, j]
+				}
+				if s > max {
+					p, max = j, s
+				}
+			}
+			if p != l {
+				for i := 0; i < n; i++ {
+					t := qr[i, l]
+					qr[i, l] = qr[i, p]
+					qr[i, p] = t
+				}
+				piv[l], piv[p] = piv[p], piv[l]
+			}
+		}
+
+		// Householder reflection H = I - tau*v*vᵀ (v[l] = 1) zeroing qr[l+1:, l]
+		alpha := qr[l, l]
+		var xnorm T
+		for i := l + 1; i < n; i++ {
+			xnorm = T(math.Hypot(float64(xnorm), float64(qr[i, l])))
+		}
+		if xnorm == 0 {
+			continue // tau[l] = 0: H = I
+		}
+		beta := -T(math.Copysign(math.Hypot(float64(alpha), float64(xnorm)), float64(alpha)))
+		tau[l] = (beta - alpha) / beta
+		for i := l + 1; i < n; i++ {
+			qr[i, l] /= alpha - beta
+		}
+		qr[l, l] = beta
+
+		// apply H to the remaining columns
+		for j := l + 1; j < m; j++ {
+			qr.reflect(l, tau[l], qr.Col(j))
+		}
+	}
+	return &QR{qr, tau, piv}
+}
+
+// reflect applies the Householder reflection I - tau*v*vᵀ, with v
+// stored in column l of qr below the diagonal (and v[l] = 1), to x.
+func (qr *Matrix) reflect(l int, tau T, x *Vector) {
+	if tau == 0 {
+		return
+	}
+	n, _ := qr.Len()
+	s := x[l]
+	for i := l + 1; i < n; i++ {
+		s += qr[i, l] * x[i]
+	}
+	s *= tau
+	x[l] -= s
+	for i := l + 1; i < n; i++ {
+		x[i] -= s * qr[i, l]
+	}
+}
+
+// Q returns the n×n orthogonal factor Q, accumulated from the
+// Householder reflections.
+func (f *QR) Q() *Matrix {
+	n, _ := f.qr.Len()
+	q := NewMatrix(n, n)
+	for j := 0; j < n; j++ {
+		x := q.Col(j)
+		x[j] = 1
+		// Q*x = H₀*(H₁*(... * x))
+		for l := len(f.tau) - 1; l >= 0; l-- {
+			f.qr.reflect(l, f.tau[l], x)
+		}
+	}
+	return q
+}
+
+// R returns the upper trapezoidal factor R, with min(n, m) rows.
+func (f *QR) R() *Matrix {
+	_, m := f.qr.Len()
+	k := len(f.tau)
+	r := NewMatrix(k, m)
+	for i := 0; i < k; i++ {
+		for j := i; j < m; j++ {
+			r[i, j] = f.qr[i, j]
+		}
+	}
+	return r
+}
+
+// Piv returns the permutation P: column i of A*P is column Piv()[i] of A.
+func (f *QR) Piv() []int { return f.piv }
+
+// Rank returns the numerical rank of A for a factorization with column
+// pivoting: the number of diagonal elements of R with absolute value
+// > tol * |R[0, 0]|. If tol < 0, max(n, m) * ε is used.
+func (f *QR) Rank(tol T) int {
+	n, m := f.qr.Len()
+	if tol < 0 {
+		tol = T(n) * epsilon
+		if m > n {
+			tol = T(m) * epsilon
+		}
+	}
+	k := len(f.tau)
+	if k == 0 || f.qr[0, 0] == 0 {
+		return 0
+	}
+	r := 0
+	for r < k && abs(f.qr[r, r]) > tol*abs(f.qr[0, 0]) {
+		r++
+	}
+	return r
+}
+
+// qtVec overwrites x with Qᵀ*x.
+func (f *QR) qtVec(x *Vector) {
+	for l, tau := range f.tau {
+		f.qr.reflect(l, tau, x)
+	}
+}
+
+// SolveVec returns the least-squares solution x minimizing ‖A*x - b‖₂
+// of the factorized n×m matrix A with m <= n; for a square A, x is the
+// solution of A*x = b. If A does not have full column rank (exactly),
+// the result is ErrSingular; for nearly rank-deficient matrices, use
+// QRPivoted and Rank to detect the numerical rank.
+func (f *QR) SolveVec(b *Vector) (*Vector, error) {
+	if !f.fullRank() {
+		return nil, ErrSingular
+	}
+	return f.solve(b), nil
+}
+
+// SolveMat is like SolveVec but solves for all columns of the matrix b
+// with the one factorization.
+func (f *QR) SolveMat(b *Matrix) (*Matrix, error) {
+	if !f.fullRank() {
+		return nil, ErrSingular
+	}
+	return f.solveMat(b), nil
+}
+
+// fullRank reports whether the factorized n×m matrix A, m <= n, has
+// (exactly) full column rank.
+func (f *QR) fullRank() bool {
+	n, m := f.qr.Len()
+	if m > n {
+		panic("underdetermined system")
+	}
+	for j := 0; j < m; j++ {
+		if f.qr[j, j] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// solveMat applies solve to each column of b.
+func (f *QR) solveMat(b *Matrix) *Matrix {
+	n, k := b.Len()
+	r, m := f.qr.Len()
+	if n != r {
+		panic("incompatible matrix sizes")
+	}
+	x := NewMatrix(m, k)
+	for j := 0; j < k; j++ {
+		copyVector(x.Col(j), f.solve(b.Col(j)))
+	}
+	return x
+}
+
+// solve returns the least-squares solution x minimizing ‖A*x - b‖₂ of
+// the factorized n×m matrix A, which must have full column rank m <= n.
+func (f *QR) solve(b *Vector) *Vector {
+	n, m := f.qr.Len()
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	if m > n {
+		panic("underdetermined system")
+	}
+	y := b.Copy()
+	f.qtVec(y)
+	// solve R[:m, :m] * z = y[:m]
+	z := &Vector{y.array, y.off, m, y.stride}
+	r := &Matrix{f.qr.array, f.qr.off, Shape{m, m}, f.qr.stride}
+	r.solveTriangular(z, true, false)
+	x := NewVector(m)
+	for j, p := range f.piv {
+		x[p] = z[j]
+	}
+	return x
+}
@@ -0,0 +1,37 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// contiguous reports whether the elements of a are stored in row-major
+// order without gaps, so that element (i, j) is at a.off + i*m + j.
+func (a *Matrix) contiguous() bool {
+	n, m := a.Len()
+	return (m <= 1 || a.stride[1] == 1) && (n <= 1 || a.stride[0] == m)
+}
+
+// Reshape returns a with its elements, taken in row-major order,
+// rearranged into an n×m matrix; n*m must equal the number of
+// elements of a. If a is stored contiguously in row-major order, the
+// result is a view sharing a's storage; otherwise it is a copy.
+func (a *Matrix) Reshape(n, m int) *Matrix {
+	if n < 0 || m < 0 || n*m != a.len.Size() {
+		panic("invalid length")
+	}
+	if !a.contiguous() {
+		a = a.Copy()
+	}
+	return &Matrix{a.array, a.off, Shape{n, m}, Shape{m, 1}}
+}
+
+// Flatten returns the elements of a in row-major order as a vector; it
+// is a view or a copy under the same condition as Reshape.
+func (a *Matrix) Flatten() *Vector {
+	if !a.contiguous() {
+		a = a.Copy()
+	}
+	return &Vector{a.array, a.off, a.len.Size(), 1}
+}
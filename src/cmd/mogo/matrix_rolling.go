@@ -0,0 +1,52 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// RollingSum returns the sums over a sliding window of window
+// consecutive rows of the n×m matrix a, computed down each column:
+// row i of the (n-window+1)×m result is the sum of rows i through
+// i+window-1 of a. The sums are updated incrementally in O(n*m) time,
+// with compensated arithmetic so that rounding errors do not
+// accumulate along long columns.
+func (a *Matrix) RollingSum(window int) *Matrix {
+	n, m := a.Len()
+	if window < 1 || window > n {
+		panic("invalid window")
+	}
+	c := NewMatrix(n-window+1, m)
+	for j := 0; j < m; j++ {
+		var s, e T // s+e is the running sum
+		add := func(x T) {
+			var d T
+			s, d = twoSum(s, x)
+			e += d
+		}
+		for i := 0; i < n; i++ {
+			add(a[i, j])
+			if i >= window {
+				add(-a[i-window, j])
+			}
+			if i >= window-1 {
+				c[i-window+1, j] = s + e
+			}
+		}
+	}
+	return c
+}
+
+// RollingMean returns the means over a sliding window of window
+// consecutive rows of a (see RollingSum).
+func (a *Matrix) RollingMean(window int) *Matrix {
+	c := a.RollingSum(window)
+	n, m := c.Len()
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			c[i, j] /= T(window)
+		}
+	}
+	return c
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "math/rand"
+
+// ShuffleRows permutes the rows of a in place into a uniformly random
+// order drawn from src, and returns the permutation: row i of the
+// result is the original row perm[i]. Applying the same permutation
+// to labels keeps them aligned with the rows.
+func (a *Matrix) ShuffleRows(src rand.Source) (perm []int) {
+	n, m := a.Len()
+	r := rand.New(src)
+	perm = make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	// Fisher-Yates
+	for i := n - 1; i > 0; i-- {
+		j := r.Intn(i + 1)
+		perm[i], perm[j] = perm[j], perm[i]
+		if i != j {
+			x, y := a.Row(i), a.Row(j)
+			for k := 0; k < m; k++ {
+				t := x[k]
+				x[k] = y[k]
+				y[k] = t
+			}
+		}
+	}
+	return perm
+}
+
+// SplitRows splits a into its first round(frac*n) rows and the
+// remaining rows, for instance into a training and a test set after
+// ShuffleRows. The results are views sharing a's storage; use Copy
+// to obtain independent matrices.
+func (a *Matrix) SplitRows(frac float64) (first, rest *Matrix) {
+	if !(frac >= 0 && frac <= 1) {
+		panic("fraction out of range")
+	}
+	n, _ := a.Len()
+	k := int(frac*float64(n) + 0.5)
+	return rowRange(a, 0, k), rowRange(a, k, n)
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "math"
+
+// A Scaler is a fitted affine transformation x -> (x - Offset[k]) / Scale[k]
+// applied along an axis of a matrix: for Axis 0, k is the column index
+// (each column is transformed with its own parameters); for Axis 1,
+// k is the row index.
+type Scaler struct {
+	Axis          int
+	Offset, Scale *Vector
+}
+
+// Standardize returns the z-scores of a along axis (see Scaler), that
+// is, a transformed to zero mean and unit (population) standard
+// deviation, and the fitted Scaler, which applies the same
+// transformation to new data. Constant columns (or rows) are only
+// centered.
+func (a *Matrix) Standardize(axis int) (*Matrix, *Scaler) {
+	s := fitScaler(a, axis, func(x *Vector) (offset, scale T) {
+		n := T(x.Len())
+		var mean T
+		for i := 0; i < x.Len(); i++ {
+			mean += x[i]
+		}
+		mean /= n
+		var v T
+		for i := 0; i < x.Len(); i++ {
+			d := x[i] - mean
+			v += d * d
+		}
+		return mean, sqrt(v / n)
+	})
+	return s.Transform(a), s
+}
+
+// MinMaxScale returns a scaled to the range [0, 1] along axis (see
+// Scaler), and the fitted Scaler, which applies the same
+// transformation to new data. Constant columns (or rows) are
+// mapped to 0.
+func (a *Matrix) MinMaxScale(axis int) (*Matrix, *Scaler) {
+	s := fitScaler(a, axis, func(x *Vector) (offset, scale T) {
+		min, max := T(math.Inf(1)), T(math.Inf(-1))
+		for i := 0; i < x.Len(); i++ {
+			if x[i] < min {
+				min = x[i]
+			}
+			if x[i] > max {
+				max = x[i]
+			}
+		}
+		return min, max - min
+	})
+	return s.Transform(a), s
+}
+
+// fitScaler returns the Scaler for a along axis whose parameters are
+// computed by fit for each column (or row). A zero scale is replaced
+// by 1.
+func fitScaler(a *Matrix, axis int, fit func(x *Vector) (offset, scale T)) *Scaler {
+	b := a
+	switch axis {
+	case 0:
+		b = a.Transpose()
+	case 1:
+	default:
+		panic("invalid axis")
+	}
+	n, m := b.Len()
+	if m == 0 {
+		panic("invalid length")
+	}
+	s := &Scaler{axis, NewVector(n), NewVector(n)}
+	for k := 0; k < n; k++ {
+		offset, scale := fit(b.Row(k))
+		if scale == 0 {
+			scale = 1
+		}
+		s.Offset[k] = offset
+		s.Scale[k] = scale
+	}
+	return s
+}
+
+// Transform returns a copy of a transformed by s.
+func (s *Scaler) Transform(a *Matrix) *Matrix {
+	return s.apply(a, func(x, offset, scale T) T { return (x - offset) / scale })
+}
+
+// InverseTransform returns a copy of a transformed by the inverse of s,
+// mapping transformed data back to the original units.
+func (s *Scaler) InverseTransform(a *Matrix) *Matrix {
+	return s.apply(a, func(x, offset, scale T) T { return x*scale + offset })
+}
+
+func (s *Scaler) apply(a *Matrix, f func(x, offset, scale T) T) *Matrix {
+	n, m := a.Len()
+	if s.Axis == 0 && m != s.Offset.Len() || s.Axis == 1 && n != s.Offset.Len() {
+		panic("incompatible matrix and vector sizes")
+	}
+	c := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			k := j
+			if s.Axis == 1 {
+				k = i
+			}
+			c[i, j] = f(a[i, j], s.Offset[k], s.Scale[k])
+		}
+	}
+	return c
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A SolvePath identifies the algorithm chosen by SmartSolve.
+type SolvePath int
+
+const (
+	PathDiagonal        SolvePath = iota // elementwise division
+	PathUpperTriangular                  // back substitution
+	PathLowerTriangular                  // forward substitution
+	PathTridiagonal                      // Thomas algorithm, O(n)
+	PathCholesky                         // Cholesky factorization
+	PathLU                               // LU factorization with partial pivoting
+)
+
+var solvePathNames = [...]string{
+	PathDiagonal:        "diagonal",
+	PathUpperTriangular: "upper triangular",
+	PathLowerTriangular: "lower triangular",
+	PathTridiagonal:     "tridiagonal",
+	PathCholesky:        "Cholesky",
+	PathLU:              "LU",
+}
+
+func (p SolvePath) String() string {
+	if uint(p) < uint(len(solvePathNames)) {
+		return solvePathNames[p]
+	}
+	return "unknown"
+}
+
+// SmartSolve solves a*x = b for the square matrix a, choosing the
+// cheapest applicable algorithm based on the (exact) structure of a:
+// diagonal and triangular systems are solved directly, diagonally
+// dominant tridiagonal systems with the Thomas algorithm, symmetric
+// systems with Cholesky if a turns out to be positive definite, and
+// all others with LU. It returns the solution and the path taken.
+// If a is singular, the result is ErrSingular.
+func (a *Matrix) SmartSolve(b *Vector) (x *Vector, path SolvePath, err error) {
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+
+	lower, upper := a.Bandwidth(0)
+	switch {
+	case lower == 0 || upper == 0:
+		// diagonal or triangular
+		path = PathDiagonal
+		if upper > 0 {
+			path = PathUpperTriangular
+		} else if lower > 0 {
+			path = PathLowerTriangular
+		}
+		for i := 0; i < n; i++ {
+			if a[i, i] == 0 {
+				return nil, path, ErrSingular
+			}
+		}
+		if path == PathDiagonal {
+			x = NewVector(n)
+			for i := 0; i < n; i++ {
+				x[i] = b[i] / a[i, i]
+			}
+			return x, path, nil
+		}
+		return a.SolveTriangular(b, path == PathUpperTriangular, false), path, nil
+
+	case lower == 1 && upper == 1 && diagonallyDominant(a):
+		sub, diag, super := NewVector(n-1), NewVector(n), NewVector(n-1)
+		for i := 0; i < n; i++ {
+			diag[i] = a[i, i]
+			if i > 0 {
+				sub[i-1] = a[i, i-1]
+				super[i-1] = a[i-1, i]
+			}
+		}
+		x, err = SolveTridiagonal(sub, diag, super, b)
+		return x, PathTridiagonal, err
+	}
+
+	if a.IsSymmetric(0) {
+		if f, err := a.Cholesky(); err == nil {
+			x = b.Copy()
+			f.solve(x)
+			return x, PathCholesky, nil
+		}
+	}
+	x, err = a.Solve(b, nil)
+	return x, PathLU, err
+}
+
+// diagonallyDominant reports whether each diagonal element of the
+// square matrix a is at least as large in magnitude as the sum of
+// the magnitudes of the other elements in its row, which makes
+// Gaussian elimination without pivoting stable.
+func diagonallyDominant(a *Matrix) bool {
+	n, _ := a.Len()
+	for i := 0; i < n; i++ {
+		var s T
+		for j := 0; j < n; j++ {
+			if j != i {
+				s += abs(a[i, j])
+			}
+		}
+		if abs(a[i, i]) < s {
+			return false
+		}
+	}
+	return true
+}
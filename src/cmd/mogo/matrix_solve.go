@@ -0,0 +1,406 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"errors"
+	"math"
+	"unsafe"
+)
+
+// ErrNoConvergence is returned by iterative methods that did not
+// converge within their iteration limit.
+var ErrNoConvergence = errors.New("iteration did not converge")
+
+// SolveOptions controls the behavior of Solve.
+// A nil *SolveOptions is equivalent to the zero value.
+type SolveOptions struct {
+	// Refine is the maximum number of iterative refinement steps.
+	// Each step computes the residual r = b - a*x (using compensated
+	// arithmetic), solves a*d = r with the existing factorization, and
+	// updates x += d. Refinement stops early once the correction no
+	// longer shrinks.
+	Refine int
+
+	// If Equilibrate is set, the system is equilibrated before
+	// solving (see Equilibrate).
+	Equilibrate bool
+}
+
+// Solve solves a*x = b for x using the LU factorization of the square
+// matrix a, refining the solution if requested by opts. If a is
+// singular, the result is ErrSingular.
+func (a *Matrix) Solve(b *Vector, opts *SolveOptions) (*Vector, error) {
+	if opts == nil {
+		opts = new(SolveOptions)
+	}
+	var r, c *Vector
+	if opts.Equilibrate {
+		// solve (R*a*C) * y = R*b, then x = C*y
+		a, r, c = a.Equilibrate()
+		b = b.Copy()
+		for i := 0; i < b.Len(); i++ {
+			b[i] *= r[i]
+		}
+	}
+	f, err := a.LU()
+	if err != nil {
+		return nil, err
+	}
+	x := b.Copy()
+	f.solve(x)
+	refine(a, b, x, f.solve, opts.Refine)
+	if opts.Equilibrate {
+		x = UnscaleSolution(c, x)
+	}
+	return x, nil
+}
+
+// Equilibrate returns row and column scale factors r and c and the
+// scaled matrix s = R*a*C, where R and C are the diagonal matrices with
+// diagonals r and c, such that the largest absolute element in each row
+// and column of s is in [0.5, 1] (barring zero rows or columns, which
+// are not scaled). The factors are powers of 2, so scaling introduces
+// no rounding errors. Solving s*y = R*b instead of a*x = b, followed by
+// x = UnscaleSolution(c, y), is more robust for badly scaled a.
+func (a *Matrix) Equilibrate() (s *Matrix, r, c *Vector) {
+	n, m := a.Len()
+	r, c = NewVector(n), NewVector(m)
+	for i := 0; i < n; i++ {
+		var max T
+		for j := 0; j < m; j++ {
+			if t := abs(a[i, j]); t > max {
+				max = t
+			}
+		}
+		r[i] = scale2(max)
+	}
+	for j := 0; j < m; j++ {
+		var max T
+		for i := 0; i < n; i++ {
+			if t := abs(r[i] * a[i, j]); t > max {
+				max = t
+			}
+		}
+		c[j] = scale2(max)
+	}
+	s = NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			s[i, j] = r[i] * a[i, j] * c[j]
+		}
+	}
+	return
+}
+
+// scale2 returns the largest power of 2 not exceeding 1/max,
+// or 1 if max is 0 or not finite.
+func scale2(max T) T {
+	if max == 0 || math.IsInf(float64(max), 0) || math.IsNaN(float64(max)) {
+		return 1
+	}
+	frac, exp := math.Frexp(float64(max)) // max = frac * 2**exp, 0.5 <= frac < 1
+	if frac == 0.5 {
+		exp-- // max is a power of 2
+	}
+	return T(math.Ldexp(1, -exp))
+}
+
+// UnscaleSolution returns the solution x = C*y of the original system,
+// given the column scale factors c returned by Equilibrate and the
+// solution y of the equilibrated system.
+func UnscaleSolution(c, y *Vector) *Vector {
+	if c.Len() != y.Len() {
+		panic("incompatible vector lengths")
+	}
+	x := NewVector(y.Len())
+	for i := 0; i < y.Len(); i++ {
+		x[i] = c[i] * y[i]
+	}
+	return x
+}
+
+// refine improves the solution x of a*x = b by at most iters steps of
+// iterative refinement, using solve to overwrite a vector r with the
+// (approximate) solution of a*d = r.
+func refine(a *Matrix, b, x *Vector, solve func(*Vector), iters int) {
+	prev := T(math.Inf(1))
+	for k := 0; k < iters; k++ {
+		d := residual(a, x, b)
+		solve(d)
+		norm := vecNormInf(d)
+		if !(norm < prev) {
+			break // not converging (anymore)
+		}
+		for i := 0; i < x.Len(); i++ {
+			x[i] += d[i]
+		}
+		if norm <= T(epsilon)*vecNormInf(x) {
+			break // converged
+		}
+		prev = norm / 2
+	}
+}
+
+// epsilon is the machine epsilon for T.
+const epsilon = 1.0 / (1 << 52)
+
+// residual returns b - a*x, computed with compensated (twice the
+// working precision) arithmetic.
+func residual(a *Matrix, x, b *Vector) *Vector {
+	n, m := a.Len()
+	if x.Len() != m || b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	r := NewVector(n)
+	for i := 0; i < n; i++ {
+		s, c := b[i], T(0)
+		for j := 0; j < m; j++ {
+			p, pe := twoProd(-a[i, j], x[j])
+			t, te := twoSum(s, p)
+			s, c = t, c+pe+te
+		}
+		r[i] = s + c
+	}
+	return r
+}
+
+// twoSum returns s = fl(a+b) and the rounding error e, so that a+b = s+e exactly.
+func twoSum(a, b T) (s, e T) {
+	s = a + b
+	z := s - a
+	e = (a - (s - z)) + (b - z)
+	return
+}
+
+// twoProd returns p = fl(a*b) and the rounding error e, so that a*b = p+e
+// exactly, using Dekker's splitting.
+func twoProd(a, b T) (p, e T) {
+	p = a * b
+	ah, al := split(a)
+	bh, bl := split(b)
+	e = al*bl - (((p - ah*bh) - al*bh) - ah*bl)
+	return
+}
+
+// split splits a into hi + lo, each with half of the significand bits
+// (Dekker's splitting, with factor 2^⌈p/2⌉ + 1 for p significand bits).
+func split(a T) (hi, lo T) {
+	factor := T(1<<27 + 1) // float64: p = 53
+	if unsafe.Sizeof(a) == 4 {
+		factor = 1<<12 + 1 // float32: p = 24
+	}
+	c := factor * a
+	hi = c - (c - a)
+	lo = a - hi
+	return
+}
+
+// vecNormInf returns the ∞-norm (maximum absolute value) of x.
+func vecNormInf(x *Vector) T {
+	var max T
+	for i := 0; i < x.Len(); i++ {
+		if t := abs(x[i]); t > max {
+			max = t
+		}
+	}
+	return max
+}
+
+// SolveTriangular solves a*x = b for x, where a is a square triangular
+// matrix: upper triangular if upper is set, lower triangular otherwise.
+// Only the respective triangle of a is accessed; if unitDiag is set,
+// the diagonal elements are assumed to be 1 and not accessed either.
+// If a has a zero on the diagonal, x contains Inf or NaN elements.
+func (a *Matrix) SolveTriangular(b *Vector, upper, unitDiag bool) *Vector {
+	x := b.Copy()
+	a.solveTriangular(x, upper, unitDiag)
+	return x
+}
+
+// SolveTriangularMat is like SolveTriangular but solves a*X = B for
+// the matrix X, with one right-hand side per column of B.
+func (a *Matrix) SolveTriangularMat(b *Matrix, upper, unitDiag bool) *Matrix {
+	x := b.Copy()
+	_, m := x.Len()
+	for j := 0; j < m; j++ {
+		a.solveTriangular(x.Col(j), upper, unitDiag)
+	}
+	return x
+}
+
+// solveTriangular overwrites x with the solution of a*y = x,
+// using forward or back substitution.
+func (a *Matrix) solveTriangular(x *Vector, upper, unitDiag bool) {
+	n, m := a.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	if x.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	if upper {
+		for i := n - 1; i >= 0; i-- {
+			t := x[i]
+			for j := i + 1; j < n; j++ {
+				t -= a[i, j] * x[j]
+			}
+			if !unitDiag {
+				t /= a[i, i]
+			}
+			x[i] = t
+		}
+		return
+	}
+	for i := 0; i < n; i++ {
+		t := x[i]
+		for j := 0; j < i; j++ {
+			t -= a[i, j] * x[j]
+		}
+		if !unitDiag {
+			t /= a[i, i]
+		}
+		x[i] = t
+	}
+}
+
+// norm1 returns the 1-norm (maximum absolute column sum) of a.
+func norm1(a *Matrix) T {
+	n, m := a.Len()
+	var max T
+	for j := 0; j < m; j++ {
+		var s T
+		for i := 0; i < n; i++ {
+			s += abs(a[i, j])
+		}
+		if s > max {
+			max = s
+		}
+	}
+	return max
+}
+
+// vecNorm1 returns the 1-norm (sum of absolute values) of x.
+func vecNorm1(x *Vector) T {
+	var s T
+	for i := 0; i < x.Len(); i++ {
+		s += abs(x[i])
+	}
+	return s
+}
+
+// rcond returns an estimate of 1/(anorm * ‖A⁻¹‖₁) for the n×n matrix A
+// with 1-norm anorm, given functions overwriting a vector x with A⁻¹*x
+// and A⁻ᵀ*x, respectively. ‖A⁻¹‖₁ is estimated using Hager's method
+// with Higham's refinements (see Higham, "FORTRAN codes for estimating
+// the one-norm of a real or complex matrix", 1988).
+func rcond(n int, anorm T, solve, solveTrans func(x *Vector)) T {
+	if n == 0 {
+		return 1
+	}
+	if anorm == 0 {
+		return 0
+	}
+	ainvnorm := invNorm1Est(n, solve, solveTrans)
+	if ainvnorm == 0 || ainvnorm != ainvnorm || math.IsInf(float64(ainvnorm), 0) {
+		return 0 // singular
+	}
+	return 1 / (anorm * ainvnorm)
+}
+
+func invNorm1Est(n int, solve, solveTrans func(x *Vector)) T {
+	const maxIter = 5
+	x := NewVector(n)
+	for i := 0; i < n; i++ {
+		x[i] = 1 / T(n)
+	}
+	var est T
+	for iter := 0; iter < maxIter; iter++ {
+		y := x.Copy()
+		solve(y)
+		norm := vecNorm1(y)
+		if iter > 0 && norm <= est {
+			break
+		}
+		est = norm
+
+		// z = A⁻ᵀ * sign(y) is a subgradient; move to its largest component
+		z := y
+		for i := 0; i < n; i++ {
+			if z[i] >= 0 {
+				z[i] = 1
+			} else {
+				z[i] = -1
+			}
+		}
+		solveTrans(z)
+		j := 0
+		for i := 1; i < n; i++ {
+			if abs(z[i]) > abs(z[j]) {
+				j = i
+			}
+		}
+		if abs(z[j]) <= z*x {
+			break // local maximum
+		}
+		for i := 0; i < n; i++ {
+			x[i] = 0
+		}
+		x[j] = 1
+	}
+
+	// Higham's alternative estimate guards against pathological cases
+	for i := 0; i < n; i++ {
+		x[i] = 1
+		if n > 1 {
+			x[i] += T(i) / T(n-1)
+		}
+		if i%2 == 1 {
+			x[i] = -x[i]
+		}
+	}
+	solve(x)
+	if alt := 2 * vecNorm1(x) / T(3*n); alt > est {
+		est = alt
+	}
+	return est
+}
+
+// SolveTridiagonal solves the tridiagonal system a*x = b in O(n) time,
+// where the n×n matrix a has subdiagonal sub, diagonal diag, and
+// superdiagonal super (with n-1, n, and n-1 elements, respectively).
+// It uses Gaussian elimination without pivoting (Thomas algorithm),
+// which is stable for diagonally dominant or SPD matrices. If a zero
+// pivot is encountered, the result is ErrSingular.
+func SolveTridiagonal(sub, diag, super, b *Vector) (*Vector, error) {
+	n := diag.Len()
+	if b.Len() != n || n > 0 && (sub.Len() != n-1 || super.Len() != n-1) {
+		panic("incompatible vector lengths")
+	}
+	if n == 0 {
+		return NewVector(0), nil
+	}
+	c := NewVector(n) // modified superdiagonal
+	x := b.Copy()
+	d := diag[0]
+	for i := 0; ; i++ {
+		if d == 0 {
+			return nil, ErrSingular
+		}
+		x[i] /= d
+		if i == n-1 {
+			break
+		}
+		c[i] = super[i] / d
+		d = diag[i+1] - sub[i]*c[i]
+		x[i+1] -= sub[i] * x[i]
+	}
+	for i := n - 2; i >= 0; i-- {
+		x[i] -= c[i] * x[i+1]
+	}
+	return x, nil
+}
@@ -0,0 +1,307 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "sort"
+
+// A CSR is a sparse matrix in compressed sparse row format. The column
+// indices and values of the nonzero elements of row i are
+// ind[ptr[i]:ptr[i+1]] and val[ptr[i]:ptr[i+1]], with the column
+// indices in increasing order.
+type CSR struct {
+	len Shape
+	ptr []int // len[0]+1 row pointers
+	ind []int
+	val []T
+}
+
+func (s *CSR) Len() (int, int) { return s.len[0], s.len[1] }
+
+func (s *CSR) [] (i, j int) T {
+	if boundsChecks && (uint(i) >= uint(s.len[0]) || uint(j) >= uint(s.len[1])) {
+		panic("index out of bounds")
+	}
+	ind := s.ind[s.ptr[i]:s.ptr[i+1]]
+	if k := sort.SearchInts(ind, j); k < len(ind) && ind[k] == j {
+		return s.val[s.ptr[i]+k]
+	}
+	return 0
+}
+
+// A Sparse is a sparse matrix in any storage format.
+type Sparse interface {
+	Len() (int, int)
+	// NNZ returns the number of stored elements.
+	NNZ() int
+	// NonZeros calls f for each stored element (i, j) with value v,
+	// until f returns false. Formats that store only a triangle of a
+	// symmetric matrix also visit the implied elements of the other
+	// triangle, so f may be called more than NNZ times.
+	NonZeros(f func(i, j int, v T) bool)
+}
+
+// NNZ returns the number of stored elements of s.
+func (s *CSR) NNZ() int { return s.ptr[s.len[0]] }
+
+// NonZeros calls f for each stored element of s in row-major order,
+// until f returns false.
+func (s *CSR) NonZeros(f func(i, j int, v T) bool) {
+	for i := 0; i < s.len[0]; i++ {
+		for k := s.ptr[i]; k < s.ptr[i+1]; k++ {
+			if !f(i, s.ind[k], s.val[k]) {
+				return
+			}
+		}
+	}
+}
+
+// MulVec returns the matrix-vector product s*x.
+func (s *CSR) MulVec(x *Vector) *Vector {
+	n, m := s.Len()
+	if x.Len() != m {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(n)
+	for i := 0; i < n; i++ {
+		var t T
+		for k := s.ptr[i]; k < s.ptr[i+1]; k++ {
+			t += s.val[k] * x[s.ind[k]]
+		}
+		y[i] = t
+	}
+	return y
+}
+
+// MulVecTrans returns the matrix-vector product sᵀ*x.
+func (s *CSR) MulVecTrans(x *Vector) *Vector {
+	n, m := s.Len()
+	if x.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(m)
+	for i := 0; i < n; i++ {
+		xi := x[i]
+		for k := s.ptr[i]; k < s.ptr[i+1]; k++ {
+			y[s.ind[k]] += s.val[k] * xi
+		}
+	}
+	return y
+}
+
+// MulDense returns the product s*b of s with the dense matrix b.
+func (s *CSR) MulDense(b *Matrix) *Matrix {
+	n, k := s.Len()
+	bk, m := b.Len()
+	if k != bk {
+		panic("incompatible matrix sizes")
+	}
+	c := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		ci := c.Row(i)
+		for p := s.ptr[i]; p < s.ptr[i+1]; p++ {
+			v, bl := s.val[p], b.Row(s.ind[p])
+			for j := 0; j < m; j++ {
+				ci[j] += v * bl[j]
+			}
+		}
+	}
+	return c
+}
+
+// Dense returns s as a dense matrix.
+func (s *CSR) Dense() *Matrix {
+	n, m := s.Len()
+	a := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for k := s.ptr[i]; k < s.ptr[i+1]; k++ {
+			a[i, s.ind[k]] = s.val[k]
+		}
+	}
+	return a
+}
+
+// NewCSR returns the n×m matrix in CSR format with the given row
+// pointers, column indices, and values, which it takes ownership of.
+// The column indices within a row may be in any order; the rows are
+// sorted and duplicate entries are summed.
+func NewCSR(n, m int, ptr, ind []int, val []T) *CSR {
+	if n < 0 || m < 0 || len(ptr) != n+1 || ptr[0] != 0 || len(ind) != ptr[n] || len(val) != ptr[n] {
+		panic("invalid sparse matrix")
+	}
+	for i := 0; i < n; i++ {
+		if ptr[i] > ptr[i+1] {
+			panic("invalid sparse matrix")
+		}
+	}
+	for _, j := range ind {
+		if uint(j) >= uint(m) {
+			panic("index out of bounds")
+		}
+	}
+	s := &CSR{Shape{n, m}, ptr, ind, val}
+	s.canonicalize()
+	return s
+}
+
+// canonicalize sorts each row of s by column index and sums
+// duplicate entries, in place.
+func (s *CSR) canonicalize() {
+	n, ind, val := s.len[0], s.ind, s.val
+	w := 0
+	for i := 0; i < n; i++ {
+		lo, hi := s.ptr[i], s.ptr[i+1]
+		if !sort.IsSorted(byIndex{ind[lo:hi], val[lo:hi]}) {
+			sort.Sort(byIndex{ind[lo:hi], val[lo:hi]})
+		}
+		s.ptr[i] = w
+		for k := lo; k < hi; k++ {
+			if w > s.ptr[i] && ind[w-1] == ind[k] {
+				val[w-1] += val[k]
+				continue
+			}
+			ind[w], val[w] = ind[k], val[k]
+			w++
+		}
+	}
+	s.ptr[n] = w
+	s.ind, s.val = ind[:w], val[:w]
+}
+
+// Prune returns a copy of s without the stored elements with absolute
+// value <= tol, such as values that cancelled to (nearly) zero.
+func (s *CSR) Prune(tol T) *CSR {
+	n, _ := s.Len()
+	p := &CSR{len: s.len, ptr: make([]int, n+1)}
+	for i := 0; i < n; i++ {
+		for k := s.ptr[i]; k < s.ptr[i+1]; k++ {
+			if abs(s.val[k]) > tol {
+				p.ind = append(p.ind, s.ind[k])
+				p.val = append(p.val, s.val[k])
+			}
+		}
+		p.ptr[i+1] = len(p.ind)
+	}
+	return p
+}
+
+// byIndex sorts parallel index and value slices by index.
+type byIndex struct {
+	ind []int
+	val []T
+}
+
+func (x byIndex) Len() int           { return len(x.ind) }
+func (x byIndex) Less(i, j int) bool { return x.ind[i] < x.ind[j] }
+func (x byIndex) Swap(i, j int) {
+	x.ind[i], x.ind[j] = x.ind[j], x.ind[i]
+	x.val[i], x.val[j] = x.val[j], x.val[i]
+}
+
+// eye returns the n×n identity matrix in CSR format.
+func eye(n int) *CSR {
+	t := &COO{len: Shape{n, n}}
+	for i := 0; i < n; i++ {
+		t.Append(i, i, 1)
+	}
+	return t.CSR()
+}
+
+// kron returns the Kronecker product of a and b.
+func kron(a, b *CSR) *CSR {
+	an, am := a.Len()
+	bn, bm := b.Len()
+	t := &COO{len: Shape{an * bn, am * bm}}
+	for i := 0; i < an; i++ {
+		for k := a.ptr[i]; k < a.ptr[i+1]; k++ {
+			for l := 0; l < bn; l++ {
+				for p := b.ptr[l]; p < b.ptr[l+1]; p++ {
+					t.Append(i*bn+l, a.ind[k]*bm+b.ind[p], a.val[k]*b.val[p])
+				}
+			}
+		}
+	}
+	return t.CSR()
+}
+
+// addCSR returns the sum a+b.
+func addCSR(a, b *CSR) *CSR {
+	if a.len != b.len {
+		panic("incompatible matrix sizes")
+	}
+	t := &COO{len: a.len}
+	for _, s := range [...]*CSR{a, b} {
+		for i := 0; i < s.len[0]; i++ {
+			for k := s.ptr[i]; k < s.ptr[i+1]; k++ {
+				t.Append(i, s.ind[k], s.val[k])
+			}
+		}
+	}
+	return t.CSR()
+}
+
+// Mul returns the sparse matrix product a*b, computed row by row with
+// Gustavson's algorithm. A first (symbolic) pass determines the
+// structure of each row of the result, so the result is allocated
+// only once; a second (numeric) pass computes the values. The column
+// indices of each row of the result are sorted; entries that cancel
+// to zero are kept (see Prune).
+func (a *CSR) Mul(b *CSR) *CSR {
+	n, k := a.Len()
+	bk, m := b.Len()
+	if k != bk {
+		panic("incompatible matrix sizes")
+	}
+
+	// symbolic pass: count the distinct columns of each result row;
+	// mark[j] == i means that column j already occurs in row i
+	c := &CSR{len: Shape{n, m}, ptr: make([]int, n+1)}
+	mark := make([]int, m)
+	for j := range mark {
+		mark[j] = -1
+	}
+	for i := 0; i < n; i++ {
+		nnz := 0
+		for p := a.ptr[i]; p < a.ptr[i+1]; p++ {
+			l := a.ind[p]
+			for q := b.ptr[l]; q < b.ptr[l+1]; q++ {
+				if j := b.ind[q]; mark[j] != i {
+					mark[j] = i
+					nnz++
+				}
+			}
+		}
+		c.ptr[i+1] = c.ptr[i] + nnz
+	}
+
+	// numeric pass: accumulate each row in a dense work vector
+	c.ind = make([]int, c.ptr[n])
+	c.val = make([]T, c.ptr[n])
+	acc := make([]T, m)
+	for j := range mark {
+		mark[j] = -1
+	}
+	for i := 0; i < n; i++ {
+		row := c.ind[c.ptr[i]:c.ptr[i]]
+		for p := a.ptr[i]; p < a.ptr[i+1]; p++ {
+			l, v := a.ind[p], a.val[p]
+			for q := b.ptr[l]; q < b.ptr[l+1]; q++ {
+				j := b.ind[q]
+				if mark[j] != i {
+					mark[j] = i
+					acc[j] = 0
+					row = append(row, j)
+				}
+				acc[j] += v * b.val[q]
+			}
+		}
+		sort.Ints(row)
+		for p, j := range row {
+			c.val[c.ptr[i]+p] = acc[j]
+		}
+	}
+	return c
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "sort"
+
+// A Spline is an interpolating cubic spline: a piecewise cubic,
+// twice continuously differentiable function passing through a given
+// set of points (x[i], y[i]).
+type Spline struct {
+	x, y []T
+	m    []T // second derivatives at the knots x
+}
+
+// NaturalSpline returns the natural cubic spline through the points
+// (x[i], y[i]), with zero second derivative at both ends. The knots x
+// must be strictly increasing, and there must be at least 2 of them.
+func NaturalSpline(x, y *Vector) *Spline {
+	return newSpline(x, y, false, 0, 0)
+}
+
+// ClampedSpline returns the clamped cubic spline through the points
+// (x[i], y[i]), with the given first derivatives d0 and dn at the
+// first and last knot. The knots x must be strictly increasing,
+// and there must be at least 2 of them.
+func ClampedSpline(x, y *Vector, d0, dn T) *Spline {
+	return newSpline(x, y, true, d0, dn)
+}
+
+func newSpline(xv, yv *Vector, clamped bool, d0, dn T) *Spline {
+	n := xv.Len()
+	if yv.Len() != n {
+		panic("incompatible vector lengths")
+	}
+	if n < 2 {
+		panic("too few knots")
+	}
+	x, y := xv.Copy().GoSlice(), yv.Copy().GoSlice()
+	h := make([]T, n-1)
+	for i := range h {
+		if h[i] = x[i+1] - x[i]; !(h[i] > 0) {
+			panic("knots not strictly increasing")
+		}
+	}
+
+	// tridiagonal system for the second derivatives m
+	sub, diag, super, b := NewVector(n-1), NewVector(n), NewVector(n-1), NewVector(n)
+	for i := 1; i < n-1; i++ {
+		sub[i-1] = h[i-1]
+		diag[i] = 2 * (h[i-1] + h[i])
+		super[i] = h[i]
+		b[i] = 6 * ((y[i+1]-y[i])/h[i] - (y[i]-y[i-1])/h[i-1])
+	}
+	if clamped {
+		diag[0] = 2 * h[0]
+		super[0] = h[0]
+		b[0] = 6 * ((y[1]-y[0])/h[0] - d0)
+		sub[n-2] = h[n-2]
+		diag[n-1] = 2 * h[n-2]
+		b[n-1] = 6 * (dn - (y[n-1]-y[n-2])/h[n-2])
+	} else {
+		diag[0] = 1
+		diag[n-1] = 1
+	}
+	m, err := SolveTridiagonal(sub, diag, super, b)
+	if err != nil {
+		panic(err) // unreachable: the system is diagonally dominant
+	}
+	return &Spline{x, y, m.GoSlice()}
+}
+
+// At returns the value of the spline at t. Outside the knot range,
+// the spline is extrapolated using its first or last cubic piece.
+func (s *Spline) At(t T) T {
+	// find the interval [x[i], x[i+1]] containing t
+	i := sort.Search(len(s.x), func(i int) bool { return s.x[i] > t }) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i > len(s.x)-2 {
+		i = len(s.x) - 2
+	}
+	h := s.x[i+1] - s.x[i]
+	a := (s.x[i+1] - t) / h
+	b := (t - s.x[i]) / h
+	return a*s.y[i] + b*s.y[i+1] + ((a*a*a-a)*s.m[i]+(b*b*b-b)*s.m[i+1])*h*h/6
+}
+
+// Eval returns the values of the spline at the elements of t.
+func (s *Spline) Eval(t *Vector) *Vector {
+	v := NewVector(t.Len())
+	for i := 0; i < t.Len(); i++ {
+		v[i] = s.At(t[i])
+	}
+	return v
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// StackRows returns the matrix with rows vs[0], vs[1], ..., for
+// instance the data matrix of a set of per-sample feature vectors.
+// All vectors must have the same length. The result has its own
+// storage, allocated at once.
+func StackRows(vs ...*Vector) *Matrix {
+	m := stackLen(vs)
+	a := NewMatrix(len(vs), m)
+	for i, v := range vs {
+		copyVector(a.Row(i), v)
+	}
+	return a
+}
+
+// StackCols returns the matrix with columns vs[0], vs[1], ....
+// All vectors must have the same length.
+func StackCols(vs ...*Vector) *Matrix {
+	n := stackLen(vs)
+	a := NewMatrix(n, len(vs))
+	for j, v := range vs {
+		copyVector(a.Col(j), v)
+	}
+	return a
+}
+
+// stackLen returns the common length of vs, or 0 if vs is empty.
+func stackLen(vs []*Vector) int {
+	if len(vs) == 0 {
+		return 0
+	}
+	n := vs[0].Len()
+	for _, v := range vs[1:] {
+		if v.Len() != n {
+			panic("incompatible vector lengths")
+		}
+	}
+	return n
+}
+
+// HStack returns the matrix [a₀ a₁ ...] obtained by placing the
+// matrices side by side. All matrices must have the same number of
+// rows.
+func HStack(as ...*Matrix) *Matrix {
+	n, m := 0, 0
+	for i, a := range as {
+		if i > 0 && a.len[0] != n {
+			panic("incompatible matrix sizes")
+		}
+		n = a.len[0]
+		m += a.len[1]
+	}
+	c := NewMatrix(n, m)
+	j := 0
+	for _, a := range as {
+		CopyTo(c.Slice(0, n, j, j+a.len[1]), a)
+		j += a.len[1]
+	}
+	return c
+}
+
+// VStack returns the matrix obtained by placing the matrices on top of
+// each other, a₀ first. All matrices must have the same number of
+// columns.
+func VStack(as ...*Matrix) *Matrix {
+	n, m := 0, 0
+	for k, a := range as {
+		if k > 0 && a.len[1] != m {
+			panic("incompatible matrix sizes")
+		}
+		n += a.len[0]
+		m = a.len[1]
+	}
+	c := NewMatrix(n, m)
+	i := 0
+	for _, a := range as {
+		CopyTo(c.Slice(i, i+a.len[0], 0, m), a)
+		i += a.len[0]
+	}
+	return c
+}
+
+// Augment returns the augmented matrix [a b] of the linear system
+// a*x = b, with b as an additional last column.
+func Augment(a *Matrix, b *Vector) *Matrix {
+	n, m := a.Len()
+	if b.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	c := NewMatrix(n, m+1)
+	CopyTo(c.Slice(0, n, 0, m), a)
+	copyVector(c.Col(m), b)
+	return c
+}
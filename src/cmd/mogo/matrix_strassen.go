@@ -0,0 +1,110 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "sync/atomic"
+
+// strassenCutoff is the limit set by SetStrassenCutoff; 0 disables
+// Strassen multiplication.
+var strassenCutoff int32
+
+// SetStrassenCutoff makes matrix products (a*b and MulTo) use the
+// Strassen-Winograd algorithm when all of n, m, and p of an n×m by m×p
+// product are at least n, recursing on half-size blocks until one of
+// them drops below n, where the blocked kernel takes over. It returns
+// the previous cutoff. If n <= 0, which is the default, Strassen
+// multiplication is disabled.
+//
+// The algorithm does about (7/8)^d of the multiplications of the
+// standard one for recursion depth d, but it is less accurate: its
+// error is bounded only normwise, ‖a*b - c‖ <= f(n) ε ‖a‖ ‖b‖, so
+// small elements of the product may have large relative errors. A
+// cutoff of about 1024 is reasonable for products of size 2048 and
+// more.
+func SetStrassenCutoff(n int) (prev int) {
+	if n < 0 {
+		n = 0
+	}
+	return int(atomic.SwapInt32(&strassenCutoff, int32(n)))
+}
+
+// useStrassen reports whether the n×m by m×p product is computed by
+// strassenTo, and with which cutoff.
+func useStrassen(n, m, p int) (cutoff int, ok bool) {
+	c := int(atomic.LoadInt32(&strassenCutoff))
+	return c, c > 0 && n >= c && m >= c && p >= c
+}
+
+// strassenTo stores a*b in dst, whose sizes have been checked and which
+// overlaps neither a nor b. The operands are split into 2×2 blocks by
+// views; the recursion needs temporaries only for the sums of blocks
+// and one product.
+func strassenTo(dst, a, b *Matrix, cutoff int) {
+	n, m := a.Len()
+	_, p := b.Len()
+	if n < cutoff || m < cutoff || p < cutoff || n < 2 || m < 2 || p < 2 {
+		mulKernel(dst, a, b)
+		return
+	}
+	// the even leading parts, split into halves; a trailing odd row of
+	// a, column of a (row of b), or column of b is fixed up afterwards
+	hn, hm, hp := n/2, m/2, p/2
+	a11, a12 := a.Slice(0, hn, 0, hm), a.Slice(0, hn, hm, 2*hm)
+	a21, a22 := a.Slice(hn, 2*hn, 0, hm), a.Slice(hn, 2*hn, hm, 2*hm)
+	b11, b12 := b.Slice(0, hm, 0, hp), b.Slice(0, hm, hp, 2*hp)
+	b21, b22 := b.Slice(hm, 2*hm, 0, hp), b.Slice(hm, 2*hm, hp, 2*hp)
+	c11, c12 := dst.Slice(0, hn, 0, hp), dst.Slice(0, hn, hp, 2*hp)
+	c21, c22 := dst.Slice(hn, 2*hn, 0, hp), dst.Slice(hn, 2*hn, hp, 2*hp)
+
+	s1 := AddTo(NewMatrix(hn, hm), a21, a22)
+	s2 := SubTo(NewMatrix(hn, hm), s1, a11)
+	s3 := SubTo(NewMatrix(hn, hm), a11, a21)
+	s4 := SubTo(NewMatrix(hn, hm), a12, s2)
+	t1 := SubTo(NewMatrix(hm, hp), b12, b11)
+	t2 := SubTo(NewMatrix(hm, hp), b22, t1)
+	t3 := SubTo(NewMatrix(hm, hp), b22, b12)
+	t4 := SubTo(NewMatrix(hm, hp), t2, b21)
+
+	x := NewMatrix(hn, hp)
+	strassenTo(x, a11, b11, cutoff) // x = m1
+	strassenTo(c11, a12, b21, cutoff)
+	AddTo(c11, c11, x) // c11 = m1 + m2
+	strassenTo(c12, s2, t2, cutoff)
+	AddTo(x, x, c12) // x = m1 + m6
+	strassenTo(c21, s3, t3, cutoff)
+	AddTo(c21, c21, x) // c21 = m1 + m6 + m7
+	strassenTo(c22, s1, t1, cutoff)
+	AddTo(x, x, c22) // x = m1 + m5 + m6
+	// c22 = m1 + m5 + m6 + m7; AddTo would reject c21 and c22 as
+	// overlapping since their rows interleave
+	for i := 0; i < hn; i++ {
+		for j := 0; j < hp; j++ {
+			c22[i, j] += c21[i, j]
+		}
+	}
+	strassenTo(c12, s4, b22, cutoff)
+	AddTo(c12, c12, x) // c12 = m1 + m3 + m5 + m6
+	strassenTo(x, a22, t4, cutoff)
+	SubTo(c21, c21, x) // c21 = m1 - m4 + m6 + m7
+
+	if m%2 != 0 {
+		k := m - 1
+		for i := 0; i < 2*hn; i++ {
+			if aik := a[i, k]; aik != 0 {
+				for j := 0; j < 2*hp; j++ {
+					dst[i, j] += aik * b[k, j]
+				}
+			}
+		}
+	}
+	if p%2 != 0 {
+		mulKernel(dst.Slice(0, 2*hn, p-1, p), a.Slice(0, 2*hn, 0, m), b.Slice(0, m, p-1, p))
+	}
+	if n%2 != 0 {
+		mulKernel(dst.Slice(n-1, n, 0, p), a.Slice(n-1, n, 0, m), b)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// Structure predicates. Elements are compared with the absolute
+// tolerance tol: an element x counts as zero if |x| <= tol, and two
+// elements x, y as equal if |x - y| <= tol. Use tol = 0 for exact
+// tests. NaN elements are never zero or equal to anything.
+
+// IsSymmetric reports whether a is square and a[i, j] equals a[j, i]
+// for all i, j.
+func (a *Matrix) IsSymmetric(tol T) bool {
+	n, m := a.Len()
+	if n != m {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			if !(abs(a[i, j]-a[j, i]) <= tol) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// IsTriangular reports whether a is upper triangular (zero below
+// the diagonal) if upper is set, or lower triangular (zero above the
+// diagonal) otherwise. For non-square matrices, it tests for the
+// corresponding trapezoidal shape.
+func (a *Matrix) IsTriangular(upper bool, tol T) bool {
+	l, u := a.Bandwidth(tol)
+	if upper {
+		return l == 0
+	}
+	return u == 0
+}
+
+// IsDiagonal reports whether all off-diagonal elements of a are zero.
+func (a *Matrix) IsDiagonal(tol T) bool {
+	l, u := a.Bandwidth(tol)
+	return l == 0 && u == 0
+}
+
+// Bandwidth returns the lower and upper bandwidth of a: the largest
+// distances i-j and j-i of a nonzero element a[i, j] below and above
+// the diagonal, respectively (0 if there is none).
+func (a *Matrix) Bandwidth(tol T) (lower, upper int) {
+	n, m := a.Len()
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			if abs(a[i, j]) <= tol {
+				continue
+			}
+			if i-j > lower {
+				lower = i - j
+			}
+			if j-i > upper {
+				upper = j - i
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,200 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// svd returns the thin singular value decomposition a = u*diag(s)*vᵀ
+// of the n×m matrix a, with r = min(n, m) singular values s in
+// decreasing order, and u and v of size n×r and m×r. The columns of u
+// are orthonormal, except that those belonging to zero singular values
+// are zero.
+//
+// svd uses the one-sided Jacobi method, which is simple and accurate
+// but slow for large matrices; it is meant for the small dense
+// problems arising inside other algorithms.
+func svd(a *Matrix) (u *Matrix, s *Vector, v *Matrix) {
+	n, m := a.Len()
+	if n < m {
+		v, s, u = svd(a.Transpose())
+		return
+	}
+	u = a.Copy()
+	v = NewMatrix(m, m)
+	for j := 0; j < m; j++ {
+		v[j, j] = 1
+	}
+
+	// apply rotations to pairs of columns of u until they are
+	// mutually orthogonal; v accumulates the rotations
+	const maxSweeps = 60
+	for sweep := 0; sweep < maxSweeps; sweep++ {
+		rotated := false
+		for p := 0; p < m-1; p++ {
+			for q := p + 1; q < m; q++ {
+				up, uq := u.Col(p), u.Col(q)
+				alpha, beta, gamma := up*up, uq*uq, up*uq
+				if abs(gamma) <= epsilon*sqrt(alpha*beta) {
+					continue
+				}
+				rotated = true
+				zeta := (beta - alpha) / (2 * gamma)
+				t := T(math.Copysign(1, float64(zeta))) / (abs(zeta) + sqrt(1+zeta*zeta))
+				c := 1 / sqrt(1+t*t)
+				sn := c * t
+				rotatePair(up, uq, c, sn)
+				rotatePair(v.Col(p), v.Col(q), c, sn)
+			}
+		}
+		if !rotated {
+			break
+		}
+	}
+
+	sv := make([]T, m)
+	for j := range sv {
+		uj := u.Col(j)
+		sv[j] = sqrt(uj * uj)
+		if sv[j] > 0 {
+			for i := 0; i < n; i++ {
+				uj[i] /= sv[j]
+			}
+		}
+	}
+
+	// sort by decreasing singular value
+	perm := make([]int, m)
+	for j := range perm {
+		perm[j] = j
+	}
+	for i := 1; i < m; i++ {
+		for j := i; j > 0 && sv[perm[j]] > sv[perm[j-1]]; j-- {
+			perm[j], perm[j-1] = perm[j-1], perm[j]
+		}
+	}
+	su, sv2 := NewMatrix(n, m), NewMatrix(m, m)
+	s = NewVector(m)
+	for j, p := range perm {
+		s[j] = sv[p]
+		copyVector(su.Col(j), u.Col(p))
+		copyVector(sv2.Col(j), v.Col(p))
+	}
+	return su, s, sv2
+}
+
+// firstCols returns a view of the first k columns of a.
+func firstCols(a *Matrix, k int) *Matrix {
+	return &Matrix{a.array, a.off, Shape{a.len[0], k}, a.stride}
+}
+
+// rotatePair applies the plane rotation [c -s; s c] to (x, y):
+// x, y = c*x - s*y, s*x + c*y.
+func rotatePair(x, y *Vector, c, s T) {
+	for i := 0; i < x.Len(); i++ {
+		xi, yi := x[i], y[i]
+		x[i] = c*xi - s*yi
+		y[i] = s*xi + c*yi
+	}
+}
+
+// RandomizedSVD returns an approximate truncated singular value
+// decomposition a ≈ u*diag(s)*vᵀ with the k largest singular values
+// s and the corresponding n×k and m×k matrices of singular vectors
+// u and v of the n×m matrix a.
+//
+// It uses the randomized range finder of Halko, Martinsson, and Tropp:
+// a is multiplied with a Gaussian random matrix of k+oversample columns,
+// followed by iters power iterations (with reorthonormalization) to
+// sharpen the sketch when the singular values decay slowly; a small
+// oversample (5 to 10) and 1 or 2 power iterations are typical. The
+// exact SVD of the projection of a onto the sketched range yields the
+// result. The cost is dominated by 2*iters+2 products of a with thin
+// matrices. rnd is the source of randomness; with a source of the
+// same seed, the result is the same.
+func (a *Matrix) RandomizedSVD(k, oversample, iters int, rnd *rand.Rand) (u *Matrix, s *Vector, v *Matrix) {
+	n, m := a.Len()
+	r := n
+	if m < r {
+		r = m
+	}
+	if k < 0 || k > r || oversample < 0 || iters < 0 {
+		panic("invalid argument")
+	}
+	l := k + oversample
+	if l > r {
+		l = r
+	}
+
+	omega := NewMatrix(m, l)
+	for i := 0; i < m; i++ {
+		for j := 0; j < l; j++ {
+			omega[i, j] = T(rnd.NormFloat64())
+		}
+	}
+	q := a * omega
+	orthonormalize(q, rnd)
+	at := a.Transpose()
+	for it := 0; it < iters; it++ {
+		z := at * q
+		orthonormalize(z, rnd)
+		q = a * z
+		orthonormalize(q, rnd)
+	}
+
+	// a ≈ q*qᵀ*a = q*(ub*diag(s)*vᵀ)
+	ub, s, v := svd(q.Transpose() * a)
+	u = q * ub
+	return firstCols(u, k), &Vector{s.array, 0, k, 1}, firstCols(v, k)
+}
+
+// LowRank returns the factors w (n×k) and h (k×m) of the best rank-k
+// approximation w*h of the n×m matrix a in the Frobenius and 2-norms,
+// obtained by truncating its singular value decomposition: w = u*diag(s)
+// and h = vᵀ for the k largest singular values s.
+func (a *Matrix) LowRank(k int) (w, h *Matrix) {
+	n, m := a.Len()
+	if k < 0 || k > n || k > m {
+		panic("invalid rank")
+	}
+	u, s, v := svd(a)
+	w = NewMatrix(n, k)
+	for i := 0; i < n; i++ {
+		for j := 0; j < k; j++ {
+			w[i, j] = u[i, j] * s[j]
+		}
+	}
+	return w, firstCols(v, k).TransposeCopy()
+}
+
+// ApproxError returns the relative error |a - w*h| / |a| in the
+// Frobenius norm of the approximation w*h of a, for instance computed
+// by LowRank. If a is zero, the result is the absolute error.
+func (a *Matrix) ApproxError(w, h *Matrix) T {
+	n, m := a.Len()
+	if wn, _ := w.Len(); wn != n {
+		panic("incompatible matrix sizes")
+	}
+	if _, hm := h.Len(); hm != m {
+		panic("incompatible matrix sizes")
+	}
+	p := w * h
+	var e, t T
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			d := a[i, j] - p[i, j]
+			e += d * d
+			t += a[i, j] * a[i, j]
+		}
+	}
+	if t == 0 {
+		return sqrt(e)
+	}
+	return sqrt(e / t)
+}
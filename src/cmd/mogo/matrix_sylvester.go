@@ -0,0 +1,84 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// SolveSylvester returns the solution x of the Sylvester equation
+// a*x + x*b = c, where a is n×n, b is m×m, and c is n×m. The solution
+// is unique if and only if a and -b have no eigenvalue in common;
+// otherwise the result is ErrSingular. The algorithm is that of
+// Bartels and Stewart: with the Schur decompositions a = u*r*uᴴ and
+// b = v*s*vᴴ, the equation becomes r*y + y*s = uᴴ*c*v with triangular
+// r and s, which is solved for y column by column, and x = u*y*vᴴ.
+func SolveSylvester(a, b, c *Matrix) (*Matrix, error) {
+	MustBeSquare("SolveSylvester", a)
+	MustBeSquare("SolveSylvester", b)
+	n, _ := a.Len()
+	m, _ := b.Len()
+	if cn, cm := c.Len(); cn != n || cm != m {
+		panic("incompatible matrix sizes")
+	}
+	u, r, err := complexSchurOf(a)
+	if err != nil {
+		return nil, err
+	}
+	v, s, err := complexSchurOf(b)
+	if err != nil {
+		return nil, err
+	}
+
+	y := u.ConjTranspose() * c.Complex() * v
+	for j := 0; j < m; j++ {
+		// (r + s[j, j]*I) * y[:, j] = f[:, j] - Σ_{k<j} s[k, j]*y[:, k]
+		for k := 0; k < j; k++ {
+			if skj := s[k, j]; skj != 0 {
+				for i := 0; i < n; i++ {
+					y[i, j] -= skj * y[i, k]
+				}
+			}
+		}
+		for i := n - 1; i >= 0; i-- {
+			t := y[i, j]
+			for k := i + 1; k < n; k++ {
+				t -= r[i, k] * y[k, j]
+			}
+			d := r[i, i] + s[j, j]
+			if d == 0 {
+				return nil, ErrSingular
+			}
+			y[i, j] = t / d
+		}
+	}
+	return (u * y * v.ConjTranspose()).Real(), nil
+}
+
+// SolveLyapunov returns the solution x of the continuous Lyapunov
+// equation a*x + x*aᵀ = q for square a and q. For a symmetric q, x is
+// symmetric (and is made exactly so); with q = -b*bᵀ and a stable a,
+// it is the controllability Gramian. The solution is unique unless a
+// has a pair of eigenvalues λ, μ with λ + μ = 0, in which case the
+// result is ErrSingular.
+func SolveLyapunov(a, q *Matrix) (*Matrix, error) {
+	x, err := SolveSylvester(a, a.Transpose(), q)
+	if err != nil {
+		return nil, err
+	}
+	if q.IsSymmetric(0) {
+		symmetrize(x)
+	}
+	return x, nil
+}
+
+// complexSchurOf returns the complex Schur decomposition a = u*r*uᴴ of
+// the square matrix a, with u unitary and r upper triangular.
+func complexSchurOf(a *Matrix) (u, r *CMatrix, err error) {
+	t, z, err := schur(a, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, r = complexSchur(t)
+	return z.Complex() * u, r, nil
+}
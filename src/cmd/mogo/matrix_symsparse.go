@@ -0,0 +1,108 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A SymCSR is a symmetric sparse matrix of which only the lower
+// triangle, including the diagonal, is stored in CSR format. It needs
+// about half the memory of the full matrix in CSR format, which is
+// significant for large graph Laplacians and covariance matrices.
+type SymCSR struct {
+	lower *CSR
+}
+
+// Sym returns the symmetric matrix with the lower triangle of the
+// square matrix s in SymCSR format. The upper triangle of s is ignored.
+func (s *CSR) Sym() *SymCSR {
+	n, m := s.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	l := &CSR{len: s.len, ptr: make([]int, n+1)}
+	for i := 0; i < n; i++ {
+		for k := s.ptr[i]; k < s.ptr[i+1] && s.ind[k] <= i; k++ {
+			l.ind = append(l.ind, s.ind[k])
+			l.val = append(l.val, s.val[k])
+		}
+		l.ptr[i+1] = len(l.ind)
+	}
+	return &SymCSR{l}
+}
+
+func (s *SymCSR) Len() (int, int) { return s.lower.Len() }
+
+func (s *SymCSR) [] (i, j int) T {
+	if j > i {
+		i, j = j, i
+	}
+	return s.lower[i, j]
+}
+
+// NNZ returns the number of stored elements of s, those of the lower
+// triangle.
+func (s *SymCSR) NNZ() int { return s.lower.NNZ() }
+
+// NonZeros calls f for each element of the full matrix implied by
+// the stored lower triangle, until f returns false. Each stored
+// element (i, j) below the diagonal is visited as (i, j) and (j, i).
+func (s *SymCSR) NonZeros(f func(i, j int, v T) bool) {
+	s.lower.NonZeros(func(i, j int, v T) bool {
+		return f(i, j, v) && (i == j || f(j, i, v))
+	})
+}
+
+// MulVec returns the matrix-vector product s*x. Each stored element
+// below the diagonal contributes to both y[i] and y[j].
+func (s *SymCSR) MulVec(x *Vector) *Vector {
+	l := s.lower
+	n, _ := l.Len()
+	if x.Len() != n {
+		panic("incompatible matrix and vector sizes")
+	}
+	y := NewVector(n)
+	for i := 0; i < n; i++ {
+		xi, t := x[i], y[i]
+		for k := l.ptr[i]; k < l.ptr[i+1]; k++ {
+			j, v := l.ind[k], l.val[k]
+			t += v * x[j]
+			if j != i {
+				y[j] += v * xi
+			}
+		}
+		y[i] = t
+	}
+	return y
+}
+
+// CSR returns s as a full sparse matrix in CSR format.
+func (s *SymCSR) CSR() *CSR {
+	l := s.lower
+	n, _ := l.Len()
+	t := &COO{len: l.len}
+	for i := 0; i < n; i++ {
+		for k := l.ptr[i]; k < l.ptr[i+1]; k++ {
+			t.Append(i, l.ind[k], l.val[k])
+			if l.ind[k] != i {
+				t.Append(l.ind[k], i, l.val[k])
+			}
+		}
+	}
+	return t.CSR()
+}
+
+// Dense returns s as a dense matrix.
+func (s *SymCSR) Dense() *Matrix {
+	l := s.lower
+	n, _ := l.Len()
+	a := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for k := l.ptr[i]; k < l.ptr[i+1]; k++ {
+			a[i, l.ind[k]] = l.val[k]
+			a[l.ind[k], i] = l.val[k]
+		}
+	}
+	return a
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "context"
+
+// A Tensor is a rank-3 array, i.e., a batch of equally sized matrices,
+// stored contiguously: matrix k occupies the elements starting at
+// k*rows*cols, in row-major order.
+type Tensor struct {
+	array []T
+	len   [3]int
+}
+
+func NewTensor(batch, n, m int) *Tensor {
+	if batch < 0 || n < 0 || m < 0 {
+		panic("invalid length")
+	}
+	return &Tensor{make([]T, batch*n*m), [3]int{batch, n, m}}
+}
+
+func (t *Tensor) addr(k, i, j int) *T {
+	if boundsChecks && (uint(k) >= uint(t.len[0]) || uint(i) >= uint(t.len[1]) || uint(j) >= uint(t.len[2])) {
+		panic("index out of bounds")
+	}
+	return &t.array[(k*t.len[1]+i)*t.len[2]+j]
+}
+
+func (t *Tensor) Len() (int, int, int)  { return t.len[0], t.len[1], t.len[2] }
+func (t *Tensor) [] (k, i, j int) T     { return *t.addr(k, i, j) }
+func (t *Tensor) []= (k, i, j int, x T) { *t.addr(k, i, j) = x }
+
+// Matrix returns matrix k of t as a view sharing t's storage.
+func (t *Tensor) Matrix(k int) *Matrix {
+	if uint(k) >= uint(t.len[0]) {
+		panic("index out of bounds")
+	}
+	return t.view(k * t.len[1] * t.len[2])
+}
+
+// view returns the row-major matrix of t's matrix size starting at
+// element offset off of t's storage.
+func (t *Tensor) view(off int) *Matrix {
+	n, m := t.len[1], t.len[2]
+	if off < 0 || off+n*m > len(t.array) {
+		panic("index out of bounds")
+	}
+	return &Matrix{t.array[off : off+n*m], 0, Shape{n, m}, Shape{m, 1}}
+}
+
+// span returns the elements of t's storage from the start up to the
+// end of the matrix starting at element offset off, as a single row,
+// for overlap checks.
+func (t *Tensor) span(off int) *Matrix {
+	k := off + t.len[1]*t.len[2]
+	return &Matrix{t.array[:k], 0, Shape{1, k}, Shape{k, 1}}
+}
+
+// GemmStridedBatch computes the batch of matrix products
+//
+//	C_k = A_k * B_k  for k = 0, 1, ... batch-1
+//
+// where batch is the batch size of c, and A_k, B_k, and C_k are the
+// matrices starting at element k*strideA of a, k*strideB of b, and
+// k*strideC of c, respectively, with the matrix sizes of the tensors.
+// A strideA or strideB of 0 uses the same matrix for every product.
+// The products are computed in parallel, so the C_k must not overlap:
+// if batch > 1, strideC must be at least the matrix size n*p of c.
+// Neither may any C_k overlap any A_k or B_k, as they would be
+// overwritten while other products still read them.
+func GemmStridedBatch(a, b, c *Tensor, strideA, strideB, strideC int) {
+	batch, n, p := c.Len()
+	_, an, am := a.Len()
+	_, bn, bm := b.Len()
+	if an != n || bm != p || am != bn {
+		panic("incompatible matrix sizes")
+	}
+	if strideA < 0 || strideB < 0 || strideC < 0 {
+		panic("invalid stride")
+	}
+	if batch > 1 && strideC < n*p {
+		panic("overlapping matrices")
+	}
+	if batch == 0 {
+		return
+	}
+	// validate the last matrices upfront so workers don't panic midway
+	last := batch - 1
+	a.view(last * strideA)
+	b.view(last * strideB)
+	c.view(last * strideC)
+	if cs := c.span(last * strideC); overlap(cs, a.span(last*strideA)) || overlap(cs, b.span(last*strideB)) {
+		panic("overlapping matrices")
+	}
+
+	parallelFor(context.Background(), batch, 1, func(lo, hi int) {
+		for k := lo; k < hi; k++ {
+			mulKernel(c.view(k*strideC), a.view(k*strideA), b.view(k*strideB))
+		}
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// RankOneUpdate sets a = a + alpha*x*yᵀ in place (BLAS GER), where
+// the n×m matrix a, x has n, and y has m elements.
+func (a *Matrix) RankOneUpdate(alpha T, x, y *Vector) {
+	n, m := a.Len()
+	if x.Len() != n || y.Len() != m {
+		panic("incompatible matrix and vector sizes")
+	}
+	for i := 0; i < n; i++ {
+		t := alpha * x[i]
+		if t == 0 {
+			continue
+		}
+		row := a.Row(i)
+		for j := 0; j < m; j++ {
+			row[j] += t * y[j]
+		}
+	}
+}
+
+// SyrK sets c = c + alpha*a*aᵀ in place (BLAS SYRK), where c is n×n
+// and a is n×k. Each element of the symmetric update is computed once
+// and added to both triangles of c, so a symmetric c stays exactly
+// symmetric.
+func (c *Matrix) SyrK(alpha T, a *Matrix) {
+	n, m := c.Len()
+	if n != m {
+		panic("matrix not square")
+	}
+	if an, _ := a.Len(); an != n {
+		panic("incompatible matrix sizes")
+	}
+	for i := 0; i < n; i++ {
+		ai := a.Row(i)
+		for j := 0; j <= i; j++ {
+			t := alpha * (ai * a.Row(j))
+			c[i, j] += t
+			if j != i {
+				c[j, i] += t
+			}
+		}
+	}
+}
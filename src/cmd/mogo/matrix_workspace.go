@@ -0,0 +1,97 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// A Workspace is scratch memory that factorizations (LUWith,
+// CholeskyWith) borrow their storage from, so that repeatedly
+// factorizing and solving systems of the same size, such as once per
+// frame of a simulation, does not allocate new storage each time (only
+// the small factorization values themselves are allocated):
+//
+//	var ws Workspace
+//	for each frame {
+//		ws.Reset()
+//		f, err := a.LUWith(&ws)
+//		...
+//		f.SolveVecTo(x, b)
+//	}
+//
+// Memory handed out is valid until the next Reset, which makes all of
+// it available again; results that must survive a Reset have to be
+// copied. The zero value is an empty workspace ready to use. A nil
+// *Workspace is valid as well and simply allocates. A Workspace must
+// not be used by multiple goroutines concurrently.
+type Workspace struct {
+	floats     [][]T
+	ints       [][]int
+	mats       []*Matrix
+	nf, ni, nm int // number of buffers in use
+}
+
+// Reset makes all memory handed out by w available for reuse.
+func (w *Workspace) Reset() {
+	w.nf, w.ni, w.nm = 0, 0, 0
+}
+
+// floatBuf returns a zeroed slice of length n.
+func (w *Workspace) floatBuf(n int) []T {
+	if w == nil {
+		return make([]T, n)
+	}
+	if w.nf == len(w.floats) {
+		w.floats = append(w.floats, nil)
+	}
+	buf := w.floats[w.nf]
+	if cap(buf) < n {
+		buf = make([]T, n)
+		w.floats[w.nf] = buf
+	}
+	w.nf++
+	buf = buf[:n]
+	for i := range buf {
+		buf[i] = 0
+	}
+	return buf
+}
+
+// intBuf returns a zeroed slice of length n.
+func (w *Workspace) intBuf(n int) []int {
+	if w == nil {
+		return make([]int, n)
+	}
+	if w.ni == len(w.ints) {
+		w.ints = append(w.ints, nil)
+	}
+	buf := w.ints[w.ni]
+	if cap(buf) < n {
+		buf = make([]int, n)
+		w.ints[w.ni] = buf
+	}
+	w.ni++
+	buf = buf[:n]
+	for i := range buf {
+		buf[i] = 0
+	}
+	return buf
+}
+
+// matrix returns a zeroed n×m matrix with row-major storage.
+func (w *Workspace) matrix(n, m int) *Matrix {
+	if w == nil {
+		return NewMatrix(n, m)
+	}
+	if n < 0 || m < 0 {
+		panic("invalid length")
+	}
+	if w.nm == len(w.mats) {
+		w.mats = append(w.mats, new(Matrix))
+	}
+	a := w.mats[w.nm]
+	w.nm++
+	*a = Matrix{w.floatBuf(n * m), 0, Shape{n, m}, Shape{m, 1}}
+	return a
+}
@@ -0,0 +1,101 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+// WrapRows returns a matrix with rows data[0], data[1], .... All rows
+// must have the same length. If the rows are evenly spaced,
+// non-overlapping subslices of one array, as produced by slicing a
+// single allocation, the result is a view of that array, and changes
+// through either are visible in the other. Otherwise the elements are
+// copied into new contiguous storage.
+func WrapRows(data [][]T) *Matrix {
+	n := len(data)
+	m := 0
+	if n > 0 {
+		m = len(data[0])
+	}
+	for _, row := range data {
+		if len(row) != m {
+			panic("ragged rows")
+		}
+	}
+	if stride, ok := rowStride(data); ok {
+		return &Matrix{data[0][:cap(data[0])], 0, Shape{n, m}, Shape{stride, 1}}
+	}
+	return NewMatrixFromSlices(data)
+}
+
+// NewMatrixFromSlices returns a new matrix with rows data[0],
+// data[1], ..., copied into its own contiguous storage. All rows must
+// have the same length.
+func NewMatrixFromSlices(data [][]T) *Matrix {
+	n, m := len(data), 0
+	if n > 0 {
+		m = len(data[0])
+	}
+	a := NewMatrix(n, m)
+	for i, row := range data {
+		if len(row) != m {
+			panic("ragged rows")
+		}
+		copy(a.array[i*m:(i+1)*m], row)
+	}
+	return a
+}
+
+// WrapMatrix returns the n×m matrix stored in data in row-major order,
+// with row i starting at data[i*stride], as a view of data without
+// copying; stride must be at least m. It is the counterpart of GoSlice
+// for matrices, for handing buffers produced elsewhere to matrix code.
+func WrapMatrix(data []T, n, m, stride int) *Matrix {
+	if n < 0 || m < 0 {
+		panic("invalid length")
+	}
+	if stride < m {
+		panic("invalid stride")
+	}
+	if n > 0 && m > 0 && len(data) < (n-1)*stride+m {
+		panic("invalid length")
+	}
+	return &Matrix{data, 0, Shape{n, m}, Shape{stride, 1}}
+}
+
+// WrapVector returns data as a vector without copying: the inverse of
+// GoSlice for contiguous vectors.
+func WrapVector(data []T) *Vector {
+	return &Vector{data, 0, len(data), 1}
+}
+
+// rowStride reports whether the (non-empty) rows of data lie in one
+// array at evenly spaced, non-overlapping positions, and if so returns
+// the distance between consecutive rows.
+func rowStride(data [][]T) (stride int, ok bool) {
+	n := len(data)
+	if n == 0 || len(data[0]) == 0 {
+		return 0, false
+	}
+	// rows share an array if their capacities end at the same address
+	base := data[0][:cap(data[0])]
+	end := &base[len(base)-1]
+	for i, row := range data {
+		r := row[:cap(row)]
+		if &r[len(r)-1] != end {
+			return 0, false
+		}
+		off := cap(data[0]) - cap(row) // position of row[0] in base
+		if i == 1 {
+			stride = off
+		}
+		if i > 0 && off != i*stride {
+			return 0, false
+		}
+	}
+	if n == 1 {
+		stride = len(data[0])
+	}
+	return stride, stride >= len(data[0])
+}
@@ -0,0 +1,287 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+// Test helpers for code using Matrix. In a regular package layout
+// these would live in a matrixtest subpackage; mogo rewrites a single
+// package, so they are kept next to the matrix code instead.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TB is the subset of testing.TB used by the assertion helpers.
+type TB interface {
+	Errorf(format string, args ...interface{})
+}
+
+// maxReportedDiffs is the number of differing elements listed
+// individually in a failure report; the largest differences are
+// listed.
+const maxReportedDiffs = 10
+
+// AssertEqualApprox reports an error via t if want and got differ in
+// shape or if any pair of corresponding elements differs by more than
+// tol. The report is that of Diff: the shapes, the maximum and mean
+// absolute and relative errors, and the worst differing elements with
+// their indices.
+// The result reports whether the matrices are approximately equal.
+func AssertEqualApprox(t TB, want, got *Matrix, tol float64) bool {
+	if msg := diffReport(want, got, tol); msg != "" {
+		t.Errorf("%s", msg)
+		return false
+	}
+	return true
+}
+
+// Diff returns a report of the differences between a and b for
+// debugging numerical discrepancies, or the empty string if they have
+// the same shape and all corresponding elements differ by at most tol.
+// The report gives the number of elements differing by more than tol,
+// the maximum and mean absolute errors over all elements, the maximum
+// and mean relative errors (relative to the elements of a) over the
+// nonzero elements of a, and the worst offenders with their indices.
+func (a *Matrix) Diff(b *Matrix, tol float64) string {
+	return diffReport(a, b, tol)
+}
+
+// An elemDiff is a differing element in a diffReport.
+type elemDiff struct {
+	i, j      int
+	want, got float64
+	abs, rel  float64
+}
+
+// diffReport returns a description of the differences between want
+// and got, or the empty string if they are equal within tol.
+func diffReport(want, got *Matrix, tol float64) string {
+	wn, wm := want.Len()
+	gn, gm := got.Len()
+	if wn != gn || wm != gm {
+		return fmt.Sprintf("shape mismatch: want %d×%d, got %d×%d", wn, wm, gn, gm)
+	}
+
+	var ndiff, nrel int
+	var max, sum, maxRel, sumRel float64
+	var worst []elemDiff // by decreasing abs, at most maxReportedDiffs
+	for i := 0; i < wn; i++ {
+		for j := 0; j < wm; j++ {
+			w, g := float64(want[i, j]), float64(got[i, j])
+			if w != 0 {
+				nrel++
+			}
+			if w == g || math.IsNaN(w) && math.IsNaN(g) {
+				continue
+			}
+			d := math.Abs(w - g)
+			if math.IsNaN(d) {
+				d = math.Inf(1) // NaN vs. number
+			}
+			rel := d / math.Abs(w)
+			if math.IsNaN(rel) {
+				rel = math.Inf(1)
+			}
+			sum += d
+			if d > max {
+				max = d
+			}
+			// a zero want element has no meaningful relative error
+			if w != 0 {
+				sumRel += rel
+				if rel > maxRel {
+					maxRel = rel
+				}
+			}
+			if d > tol {
+				ndiff++
+				k := len(worst)
+				for k > 0 && worst[k-1].abs < d {
+					k--
+				}
+				if k < maxReportedDiffs {
+					if len(worst) < maxReportedDiffs {
+						worst = append(worst, elemDiff{})
+					}
+					copy(worst[k+1:], worst[k:])
+					worst[k] = elemDiff{i, j, w, g, d, rel}
+				}
+			}
+		}
+	}
+	if ndiff == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	for _, e := range worst {
+		fmt.Fprintf(&buf, "\n\t[%d, %d]: want %g, got %g (diff %g, rel %g)", e.i, e.j, e.want, e.got, e.abs, e.rel)
+	}
+	if ndiff > maxReportedDiffs {
+		fmt.Fprintf(&buf, "\n\t... and %d more", ndiff-maxReportedDiffs)
+	}
+	meanRel := 0.0
+	if nrel > 0 {
+		meanRel = sumRel / float64(nrel)
+	}
+	return fmt.Sprintf("%d×%d matrices differ in %d elements (tol %g, max error %g, mean error %g, max rel error %g, mean rel error %g):%s",
+		wn, wm, ndiff, tol, max, sum/float64(wn*wm), maxRel, meanRel, buf.String())
+}
+
+// Golden compares got against the matrix stored in the golden file
+// testdata/name.golden, using AssertEqualApprox. If the -update flag
+// is set, the golden file is (re)written with got instead.
+// The result reports whether the comparison succeeded.
+func Golden(t TB, name string, got *Matrix, tol float64) bool {
+	filename := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+			t.Errorf("%s", err)
+			return false
+		}
+		if err := got.SaveFile(filename); err != nil {
+			t.Errorf("%s", err)
+			return false
+		}
+		return true
+	}
+	want, err := LoadFile(filename)
+	if err != nil {
+		t.Errorf("%s (run with -update to create golden file)", err)
+		return false
+	}
+	return AssertEqualApprox(t, want, got, tol)
+}
+
+// A Structure selects the structure of matrices produced by a Gen.
+type Structure int
+
+const (
+	GenGeneral         Structure = iota // no particular structure
+	GenSymmetric                        // square and symmetric
+	GenUpperTriangular                  // zero below the diagonal
+	GenLowerTriangular                  // zero above the diagonal
+	GenSingular                         // rank-deficient
+)
+
+// A Gen generates random matrices for property-based tests.
+// A zero Gen produces general matrices with 1 to 8 rows and columns
+// and normally distributed elements.
+type Gen struct {
+	MinRows, MaxRows int // row count range (inclusive); 0 means default
+	MinCols, MaxCols int // column count range (inclusive); 0 means default
+	Structure        Structure
+	NaNProb, InfProb float64 // probability of an element being NaN or ±Inf
+}
+
+const genMaxDim = 8 // default maximum dimension
+
+func genDim(r *rand.Rand, min, max int) int {
+	if min <= 0 {
+		min = 1
+	}
+	if max <= 0 {
+		max = genMaxDim
+	}
+	if max < min {
+		max = min
+	}
+	return min + r.Intn(max-min+1)
+}
+
+// Matrix returns a new random matrix using r as the source of randomness.
+func (g *Gen) Matrix(r *rand.Rand) *Matrix {
+	n := genDim(r, g.MinRows, g.MaxRows)
+	m := genDim(r, g.MinCols, g.MaxCols)
+	if g.Structure == GenSymmetric {
+		m = n
+	}
+	a := NewMatrix(n, m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			a[i, j] = T(r.NormFloat64())
+		}
+	}
+
+	switch g.Structure {
+	case GenSymmetric:
+		for i := 0; i < n; i++ {
+			for j := 0; j < i; j++ {
+				a[j, i] = a[i, j]
+			}
+		}
+	case GenUpperTriangular, GenLowerTriangular:
+		upper := g.Structure == GenUpperTriangular
+		for i := 0; i < n; i++ {
+			for j := 0; j < m; j++ {
+				if upper && j < i || !upper && j > i {
+					a[i, j] = 0
+				}
+			}
+		}
+	case GenSingular:
+		// the rank is at most min(n, m), so a dependent row only makes a
+		// rank-deficient matrix if n <= m, and a dependent column if n >= m
+		v := a
+		if n > m {
+			v = a.Transpose()
+		}
+		// make row k of v a multiple of row l (or zero if there is only
+		// one row)
+		vn, vm := v.Len()
+		k, l := r.Intn(vn), r.Intn(vn)
+		c := T(r.NormFloat64())
+		if k == l {
+			c = 0
+		}
+		for j := 0; j < vm; j++ {
+			v[k, j] = c * v[l, j]
+		}
+	}
+
+	if g.NaNProb > 0 || g.InfProb > 0 {
+		for i := 0; i < n; i++ {
+			for j := 0; j < m; j++ {
+				switch p := r.Float64(); {
+				case p < g.NaNProb:
+					a[i, j] = T(math.NaN())
+				case p < g.NaNProb+g.InfProb:
+					a[i, j] = T(math.Inf(1 - 2*r.Intn(2)))
+				}
+			}
+		}
+	}
+	return a
+}
+
+// Values fills args with random matrices. Its signature matches
+// testing/quick.Config.Values, so a Gen can drive quick.Check
+// for functions whose arguments are all of type *Matrix:
+//
+//	quick.Check(f, &quick.Config{Values: g.Values})
+//
+func (g *Gen) Values(args []reflect.Value, r *rand.Rand) {
+	for i := range args {
+		args[i] = reflect.ValueOf(g.Matrix(r))
+	}
+}
+
+// FromBytes deterministically derives a random matrix from data,
+// for use in fuzz targets where the input is an arbitrary byte slice.
+func (g *Gen) FromBytes(data []byte) *Matrix {
+	h := fnv.New64a()
+	h.Write(data)
+	return g.Matrix(rand.New(rand.NewSource(int64(h.Sum64()))))
+}
@@ -23,7 +23,7 @@ var fset = token.NewFileSet()
 
 func handle(err error) {
 	if err != nil {
-		fmt.Println(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(-2)
 	}
 }
@@ -31,11 +31,71 @@ func handle(err error) {
 func main() {
 	flag.Parse()
 
-	// parser file
-	file, err := parser.ParseFile(fset, flag.Arg(0), nil, 0)
+	srcs, err := generate(flag.Args())
 	handle(err)
 
+	// write ASTs
+	var filenames []string
+	for i, src := range srcs {
+		filename := "generated." + flag.Arg(i)
+		handle(ioutil.WriteFile(filename, src, 0666))
+		filenames = append(filenames, filename)
+	}
+
+	// compile and run
+	out, _ := exec.Command("go", append([]string{"run"}, filenames...)...).CombinedOutput()
+	fmt.Printf("%s", out)
+}
+
+// generate parses the named files and returns their sources with the
+// operator methods and operator expressions rewritten.
+func generate(filenames []string) ([][]byte, error) {
+	// parse files
+	var files []*ast.File
+	for _, filename := range filenames {
+		file, err := parser.ParseFile(fset, filename, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
 	// rewrite operator method names
+	for _, file := range files {
+		renameMethods(file)
+	}
+
+	// rewrite operators
+	for progress := true; ; {
+		pkg, tmap, err := typecheck(files)
+		if err == nil || !progress {
+			break
+		}
+		progress = false
+		for _, file := range files {
+			p, err := rewriteOperators(pkg, tmap, file)
+			if err != nil {
+				return nil, err
+			}
+			if p {
+				progress = true
+			}
+		}
+	}
+
+	// format ASTs
+	var srcs [][]byte
+	for _, file := range files {
+		buf := bytes.NewBuffer([]byte("// +build ignore\n\n")) // don't pollute directory with buildable files
+		if err := format.Node(buf, fset, file); err != nil {
+			return nil, err
+		}
+		srcs = append(srcs, buf.Bytes())
+	}
+	return srcs, nil
+}
+
+func renameMethods(file *ast.File) {
 	ast.Apply(file, func(parent ast.Node, name string, index int, n ast.Node) bool {
 		switch n := n.(type) {
 		case *ast.InterfaceType:
@@ -58,62 +118,143 @@ func main() {
 		}
 		return true
 	}, nil)
+}
 
-	// rewrite operators
-	for progress := true; ; {
-		pkg, tmap, err := typecheck(file)
-		if err == nil || !progress {
-			break
-		}
-		progress = false
-		ast.Apply(file,
-			func(parent ast.Node, name string, index int, n ast.Node) bool {
-				switch n := n.(type) {
-				case *ast.AssignStmt:
-					if len(n.Lhs) != 1 || len(n.Rhs) != 1 {
-						break // cannot handle these cases yet
-					}
-					if lhs, ok := n.Lhs[0].(*ast.IndexExpr); ok {
-						if r := rewrite(pkg, tmap, lhs.X, "[]=", append(lhs.Index, n.Rhs[0])...); r != nil {
-							ast.SetField(parent, name, index, &ast.ExprStmt{r})
-							progress = true
-						}
-					}
+// rewriteOperators rewrites the operator expressions in file for which
+// there is a corresponding operator method. The result reports whether
+// any rewrite took place.
+//
+// x[i] op= y and x[i]++ are expanded to x[i] = x[i] op y, which
+// evaluates x and i twice; they are rejected with an error unless x
+// and i are free of side effects (see sideEffectFree).
+func rewriteOperators(pkg *types.Package, tmap map[ast.Expr]types.TypeAndValue, file *ast.File) (progress bool, err error) {
+	ast.Apply(file,
+		func(parent ast.Node, name string, index int, n ast.Node) bool {
+			var lhs, rhs ast.Expr
+			var tok token.Token
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+					break // cannot handle these cases yet
 				}
-				return true
-			},
-			func(parent ast.Node, name string, index int, n ast.Node) bool {
-				var r *ast.CallExpr
-				switch n := n.(type) {
-				case *ast.IndexExpr:
-					r = rewrite(pkg, tmap, n.X, "[]", n.Index...)
-				case *ast.BinaryExpr:
-					r = rewrite(pkg, tmap, n.X, n.Op.String(), n.Y)
+				lhs, rhs, tok = n.Lhs[0], n.Rhs[0], n.Tok
+				if op, ok := assignOp[n.Tok]; ok {
+					// x[i] op= y is x[i] = x[i] op y
+					rhs = &ast.BinaryExpr{X: lhs, Op: op, Y: rhs}
+				} else if n.Tok != token.ASSIGN {
+					lhs = nil // cannot handle these cases yet
 				}
-				if r != nil {
-					ast.SetField(parent, name, index, r)
+			case *ast.IncDecStmt:
+				// x[i]++ is x[i] = x[i] + 1
+				lhs, rhs, tok = n.X, &ast.BinaryExpr{X: n.X, Op: assignOp[n.Tok], Y: &ast.BasicLit{Kind: token.INT, Value: "1"}}, n.Tok
+			}
+			if lhs, ok := lhs.(*ast.IndexExpr); ok {
+				if r := rewrite(pkg, tmap, lhs.X, "[]=", append(lhs.Index, rhs)...); r != nil {
+					if tok != token.ASSIGN && !sideEffectFree(pkg, tmap, lhs) {
+						if err == nil {
+							err = fmt.Errorf("%s: cannot rewrite %s: operand has side effects and would be evaluated twice", fset.Position(lhs.Pos()), tok)
+						}
+						return false
+					}
+					ast.SetField(parent, name, index, &ast.ExprStmt{X: r})
 					progress = true
 				}
-				return true
-			},
-		)
-	}
+			}
+			return true
+		},
+		func(parent ast.Node, name string, index int, n ast.Node) bool {
+			var r *ast.CallExpr
+			switch n := n.(type) {
+			case *ast.IndexExpr:
+				r = rewrite(pkg, tmap, n.X, "[]", n.Index...)
+			case *ast.BinaryExpr:
+				r = rewrite(pkg, tmap, n.X, n.Op.String(), n.Y)
+			}
+			if r != nil {
+				ast.SetField(parent, name, index, r)
+				progress = true
+			}
+			return true
+		},
+	)
+	return
+}
 
-	// write AST
-	buf := bytes.NewBuffer([]byte("// +build ignore\n\n")) // don't pollute directory with buildable files
-	handle(format.Node(buf, fset, file))
-	filename := "generated." + flag.Arg(0)
-	handle(ioutil.WriteFile(filename, buf.Bytes(), 0666))
+// sideEffectFree reports whether evaluating x has no side effects, so
+// that it may be evaluated more than once: x consists of identifiers,
+// literals, selectors, index expressions, and operators other than
+// receives, and of calls only for conversions, the builtins len, cap,
+// real, imag, and complex, and operator methods.
+// The operator methods (including those called by earlier rewrites)
+// are assumed to be free of side effects, like the operators they
+// stand for.
+func sideEffectFree(pkg *types.Package, tmap map[ast.Expr]types.TypeAndValue, x ast.Expr) bool {
+	all := func(list []ast.Expr) bool {
+		for _, x := range list {
+			if !sideEffectFree(pkg, tmap, x) {
+				return false
+			}
+		}
+		return true
+	}
+	switch x := x.(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return true
+	case *ast.ParenExpr:
+		return sideEffectFree(pkg, tmap, x.X)
+	case *ast.SelectorExpr:
+		return sideEffectFree(pkg, tmap, x.X)
+	case *ast.StarExpr:
+		return sideEffectFree(pkg, tmap, x.X)
+	case *ast.UnaryExpr:
+		return x.Op != token.ARROW && sideEffectFree(pkg, tmap, x.X)
+	case *ast.BinaryExpr:
+		return sideEffectFree(pkg, tmap, x.X) && sideEffectFree(pkg, tmap, x.Y)
+	case *ast.IndexExpr:
+		return sideEffectFree(pkg, tmap, x.X) && all(x.Index)
+	case *ast.CallExpr:
+		if pureFunc(pkg, tmap, x.Fun) {
+			return all(x.Args)
+		}
+		if sel, ok := x.Fun.(*ast.SelectorExpr); ok && isOperatorMethod[sel.Sel.Name] {
+			return sideEffectFree(pkg, tmap, sel.X) && all(x.Args)
+		}
+	}
+	return false
+}
 
-	// compile and run
-	out, _ := exec.Command("go", "run", filename).CombinedOutput()
-	fmt.Printf("%s", out)
+// pureFunc reports whether fun is a type (in a conversion) or one of
+// the builtins without side effects. Expressions that failed to
+// typecheck have no type information, so for an identifier fun the
+// package and universe scopes are also consulted.
+func pureFunc(pkg *types.Package, tmap map[ast.Expr]types.TypeAndValue, fun ast.Expr) bool {
+	if tv := tmap[fun]; tv.IsType() {
+		return true
+	}
+	id, ok := fun.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	obj := pkg.Scope().Lookup(id.Name)
+	if obj == nil {
+		obj = types.Universe.Lookup(id.Name)
+	}
+	switch obj.(type) {
+	case *types.TypeName:
+		return true
+	case *types.Builtin:
+		switch id.Name {
+		case "len", "cap", "real", "imag", "complex":
+			return true
+		}
+	}
+	return false
 }
 
-func typecheck(file *ast.File) (*types.Package, map[ast.Expr]types.TypeAndValue, error) {
+func typecheck(files []*ast.File) (*types.Package, map[ast.Expr]types.TypeAndValue, error) {
 	conf := types.Config{Importer: importer.For("gc", nil), Error: func(error) {}}
 	tmap := make(map[ast.Expr]types.TypeAndValue)
-	pkg, err := conf.Check("pkg", fset, []*ast.File{file}, &types.Info{Types: tmap})
+	pkg, err := conf.Check("pkg", fset, files, &types.Info{Types: tmap})
 	return pkg, tmap, err
 }
 
@@ -135,3 +276,23 @@ var methName = map[string]string{
 	"[]":  "AT__",
 	"[]=": "ATSET__",
 }
+
+// isOperatorMethod is the set of rewritten operator method names.
+var isOperatorMethod = make(map[string]bool)
+
+func init() {
+	for _, name := range methName {
+		isOperatorMethod[name] = true
+	}
+}
+
+// assignOp maps assignment operators to their corresponding binary operators.
+var assignOp = map[token.Token]token.Token{
+	token.ADD_ASSIGN: token.ADD,
+	token.SUB_ASSIGN: token.SUB,
+	token.MUL_ASSIGN: token.MUL,
+	token.QUO_ASSIGN: token.QUO,
+	token.REM_ASSIGN: token.REM,
+	token.INC:        token.ADD,
+	token.DEC:        token.SUB,
+}
@@ -0,0 +1,172 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestGenerate compares the rewritten testdata/*.go files against the
+// corresponding .golden files.
+func TestGenerate(t *testing.T) {
+	filenames, err := filepath.Glob(filepath.Join("testdata", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range filenames {
+		srcs, err := generate([]string{filename})
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		golden := strings.TrimSuffix(filename, ".go") + ".golden"
+		if *update {
+			if err := ioutil.WriteFile(golden, srcs[0], 0666); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Errorf("%s (run with -update to create golden file)", err)
+			continue
+		}
+		if !bytes.Equal(srcs[0], want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", filename, srcs[0], want)
+		}
+	}
+}
+
+var sideEffectTests = []string{
+	`v[f()] += 1`,
+	`v[f()]++`,
+	`v[<-c]--`,
+	`vec()[0] -= 1`,
+	`v[func() int { return 0 }()] *= 2`,
+}
+
+// TestSideEffects checks that x[i] op= y and x[i]++ are rejected if x
+// or i would be evaluated twice with side effects.
+func TestSideEffects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mogo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, stmt := range sideEffectTests {
+		src := `package main
+
+type Vec []float64
+
+func (v Vec) [] (i int) float64     { return v[i] }
+func (v Vec) []= (i int, x float64) { v[i] = x }
+
+var v = &Vec{1, 2}
+var c = make(chan int)
+
+func f() int { return 0 }
+func vec() *Vec { return v }
+
+func main() {
+	` + stmt + `
+}
+`
+		filename := filepath.Join(dir, "x.go")
+		if err := ioutil.WriteFile(filename, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+		_, err := generate([]string{filename})
+		if err == nil || !strings.Contains(err.Error(), "side effects") {
+			t.Errorf("#%d %s: got error %v, want side effects error", i, stmt, err)
+		}
+	}
+}
+
+// runMatrix rewrites the matrix example without its demo (matrix*.go
+// except matrix_demo.go) together with the driver testdata/run/name,
+// runs it with go run and the given flags, and returns its output.
+func runMatrix(t *testing.T, name string, flags ...string) string {
+	testenv.MustHaveGoRun(t)
+
+	filenames, err := filepath.Glob("matrix*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, filename := range filenames {
+		if filename == "matrix_demo.go" {
+			filenames = append(filenames[:i], filenames[i+1:]...)
+			break
+		}
+	}
+	filenames = append(filenames, filepath.Join("testdata", "run", name))
+	srcs, err := generate(filenames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "mogo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	args := append([]string{"run"}, flags...)
+	for i, src := range srcs {
+		filename := filepath.Join(dir, filepath.Base(filenames[i]))
+		if err := ioutil.WriteFile(filename, src, 0666); err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, filename)
+	}
+	out, err := exec.Command(testenv.GoToolPath(t), args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %v\n%s", name, err, out)
+	}
+	return string(out)
+}
+
+// TestFrozenRace runs concurrent readers of a Frozen and of a shared
+// *Matrix with the race detector.
+func TestFrozenRace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64", "darwin/amd64", "freebsd/amd64", "windows/amd64":
+	default:
+		t.Skip("race detector not supported on " + runtime.GOOS + "/" + runtime.GOARCH)
+	}
+	out := runMatrix(t, "frozen.go", "-race")
+	if want := "[-1 0 0 0 0 0 0 0] 0\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+// TestNNLS checks NNLS for wide matrices and for badly scaled
+// right-hand sides.
+func TestNNLS(t *testing.T) {
+	out := runMatrix(t, "nnls.go")
+	want := `[1 0 2]
+[1e+12 0 2e+12]
+[1e-12 0 2e-12]
+[0 0.5 0 1.5]
+[0 5e+11 0 1.5e+12]
+[0 0 3.33333e+08]
+`
+	if out != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+}
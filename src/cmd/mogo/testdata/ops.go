@@ -0,0 +1,56 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import "fmt"
+
+type Vec struct {
+	e []float64
+}
+
+func (v Vec) [] (i int) float64     { return v.e[i] }
+func (v Vec) []= (i int, x float64) { v.e[i] = x }
+
+func (v Vec) + (w Vec) Vec {
+	u := Vec{make([]float64, len(v.e))}
+	for i := range v.e {
+		u.e[i] = v.e[i] + w.e[i]
+	}
+	return u
+}
+
+type Grid struct {
+	n    int
+	data []float64
+}
+
+func (g *Grid) [] (i, j int) float64     { return g.data[i*g.n+j] }
+func (g *Grid) []= (i, j int, x float64) { g.data[i*g.n+j] = x }
+
+type Adder interface {
+	+ (Vec) Vec
+}
+
+func main() {
+	v := &Vec{[]float64{1, 2, 3}}
+	w := Vec{[]float64{4, 5, 6}}
+	g := &Grid{2, make([]float64, 4)}
+	idx := []int{2, 0}
+
+	fmt.Println(*v + w + w)
+	var a Adder = w
+	fmt.Println(a + w)
+
+	v[0] = w[1]
+	v[1] += 2
+	v[idx[0]] *= w[2]
+	v[int(w[0])-4]--
+	g[0, 1] = v[0] + g[1, 1]
+	g[1, 0] -= 3
+	g[len(idx)-1, idx[1]]++
+	fmt.Println(v.e, g.data)
+}
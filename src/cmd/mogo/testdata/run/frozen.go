@@ -0,0 +1,51 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+// Concurrent readers of a Frozen and of a shared *Matrix that nobody
+// writes to; run with the race detector by TestFrozenRace.
+
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+func main() {
+	a := NewMatrix(8, 8)
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			a[i, j] = T(i*8 + j)
+		}
+	}
+	f := a.Freeze()
+
+	sums := make([]T, 8)
+	var wg sync.WaitGroup
+	for g := range sums {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			n, m := f.Len()
+			var s T
+			for i := 0; i < n; i++ {
+				for j := 0; j < m; j++ {
+					s += f[i, j] - a[i, j]
+				}
+				s += f.Row(i)*a.Col(i) - a.Row(i)*f.Col(i)
+			}
+			c := f.Matrix()
+			c[0, 0] = 1 // a modifiable copy private to this goroutine
+			s += (a * a.Transpose())[g, g] - (c * c.Transpose())[g, g]
+			sums[g] = s
+		}(g)
+	}
+	wg.Wait()
+
+	// changes to a after Freeze do not affect f
+	a[0, 0] = 1
+	fmt.Println(sums, f[0, 0])
+}
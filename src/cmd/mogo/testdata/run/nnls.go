@@ -0,0 +1,71 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+// NNLS for wide matrices and for right-hand sides of very different
+// magnitudes; run by TestNNLS.
+
+package main
+
+import "fmt"
+
+func vec(elems ...T) *Vector {
+	x := NewVector(len(elems))
+	for i, e := range elems {
+		x[i] = e
+	}
+	return x
+}
+
+func nnls(a *Matrix, b *Vector) {
+	x, err := a.NNLS(b)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for i := 0; i < x.Len(); i++ {
+		if x[i] < 0 {
+			fmt.Println("negative element", i, x[i])
+		}
+	}
+	if r := residual(a, x, b); r.Norm(2) > 100*epsilon*b.Norm(2) {
+		fmt.Println("large residual", r.GoSlice())
+	}
+	fmt.Printf("%.6g\n", x.GoSlice())
+}
+
+func main() {
+	// tall, with an exact non-negative solution (1, 0, 2)
+	a := NewMatrix(4, 3)
+	a.Set(
+		1, 2, 0,
+		0, 1, 1,
+		3, 0, 1,
+		1, 1, 1,
+	)
+	xs := vec(1, 0, 2)
+	for _, scale := range []T{1, 1e12, 1e-12} {
+		nnls(a, a.MulVec(xs).Scale(scale))
+	}
+
+	// wide: the passive set fills up with n = 2 columns
+	w := NewMatrix(2, 4)
+	w.Set(
+		1, 0, 1, 2,
+		0, 1, 1, 1,
+	)
+	b := vec(3, 2)
+	for _, scale := range []T{1, 1e12} {
+		nnls(w, b.Scale(scale))
+	}
+
+	// wide with dependent columns
+	d := NewMatrix(2, 3)
+	d.Set(
+		1, 2, 3,
+		1, 2, 3,
+	)
+	nnls(d, vec(1e9, 1e9))
+}